@@ -0,0 +1,276 @@
+// Command matilda-gen writes matilda table definitions for the tables of an
+// existing Postgres database.
+//
+// Usage:
+//
+//	matilda-gen -dsn postgres://user@host/db -pkg models -o tables.go
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	_ "github.com/lib/pq"
+)
+
+type column struct {
+	name string
+	typ string
+	notNull bool
+	def string
+	autoInc bool
+	pkey bool
+}
+
+type table struct {
+	name string
+	columns []*column
+}
+
+const tablesSQL = `SELECT table_name
+    FROM information_schema.tables
+    WHERE table_schema = $1 AND table_type = 'BASE TABLE'
+    ORDER BY table_name;`
+
+const columnsSQL = `SELECT a.attname, format_type(a.atttypid, a.atttypmod),
+    a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), ''),
+    a.attidentity <> '', COALESCE(a.attnum = ANY(k.conkey), false)
+    FROM pg_attribute a
+    JOIN pg_class c ON c.oid = a.attrelid
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+    LEFT JOIN pg_constraint k ON k.conrelid = c.oid AND k.contype = 'p'
+    WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0
+    AND NOT a.attisdropped
+    ORDER BY a.attnum;`
+
+func main() {
+	var err error
+
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"),
+	    "Postgres connection string")
+	schema := flag.String("schema", "public", "Postgres schema")
+	pkg := flag.String("pkg", "models", "Go package name")
+	out := flag.String("o", "", "Output file, stdout when empty")
+	only := flag.String("tables", "", "Comma separated tables, all " +
+	    "when empty")
+	flag.Parse()
+
+	if *dsn == "" {
+		fmt.Fprintln(os.Stderr, "matilda-gen: -dsn is required.")
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	tables, err := introspect(db, *schema, *only)
+	if err != nil {
+		fail(err)
+	}
+	src, err := generate(*pkg, tables)
+	if err != nil {
+		fail(err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*out, src, 0644)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+
+	fmt.Fprintln(os.Stderr, "matilda-gen:", err)
+	os.Exit(1)
+}
+
+func introspect(db *sql.DB, schema string, only string) (
+    tables []*table, err error) {
+	var names []string
+
+	if only != "" {
+		names = strings.Split(only, ",")
+	} else if names, err = tableNames(db, schema); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		t := &table{name: strings.TrimSpace(name)}
+		if t.columns, err = tableColumns(db, schema, t.name);
+		    err != nil {
+			return nil, err
+		}
+		if len(t.columns) == 0 {
+			return nil, fmt.Errorf("table %q not found", t.name)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func tableNames(db *sql.DB, schema string) (names []string, err error) {
+
+	rows, err := db.Query(tablesSQL, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func tableColumns(db *sql.DB, schema string, name string) (
+    cols []*column, err error) {
+	var identity bool
+
+	rows, err := db.Query(columnsSQL, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		col := new(column)
+		if err = rows.Scan(&col.name, &col.typ, &col.notNull, &col.def,
+		    &identity, &col.pkey); err != nil {
+			return nil, err
+		}
+		col.autoInc = identity || strings.HasPrefix(col.def, "nextval(")
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
+}
+
+// Go identifier for a database name
+func camelCase(name string) string {
+	var b strings.Builder
+	var upper = true
+
+	for _, r := range name {
+		if unicode.IsLetter(r) == false && unicode.IsDigit(r) == false {
+			upper = true
+			continue
+		}
+		if upper == true {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 || unicode.IsDigit([]rune(b.String())[0]) {
+		return "T" + b.String()
+	}
+	return b.String()
+}
+
+var varcharLen = regexp.MustCompile(`^character varying\(([0-9]+)\)$`)
+var intDefault = regexp.MustCompile(`^'?(-?[0-9]+)'?(::[a-z ]+)?$`)
+var strDefault = regexp.MustCompile(`^'((?:[^']|'')*)'(::[a-z ]+` +
+    `(\([0-9]+\))?)?$`)
+
+// Validator literal for a column, empty when there is no known validator
+func validator(col *column) string {
+	var opts []string
+	var vdr string
+
+	// Auto incremental values are missing until the database sets them
+	if col.notNull == true && col.autoInc == false {
+		opts = append(opts, "NotNull: true")
+	}
+	switch {
+	case col.typ == "bigint":
+		vdr = "VdrInt64"
+		if m := intDefault.FindStringSubmatch(col.def);
+		    m != nil && col.autoInc == false {
+			opts = append(opts, "Default: int64(" + m[1] + ")")
+		}
+	case col.typ == "integer" || col.typ == "smallint":
+		vdr = "VdrInt32"
+		if m := intDefault.FindStringSubmatch(col.def);
+		    m != nil && col.autoInc == false {
+			opts = append(opts, "Default: int32(" + m[1] + ")")
+		}
+	case col.typ == "text" || strings.HasPrefix(col.typ, "character"):
+		vdr = "VdrString"
+		if m := varcharLen.FindStringSubmatch(col.typ); m != nil {
+			opts = append(opts, "MaxLen: " + m[1])
+		}
+		if m := strDefault.FindStringSubmatch(col.def); m != nil {
+			opts = append(opts, "Default: " + strconv.Quote(
+			    strings.Replace(m[1], "''", "'", -1)))
+		}
+	case col.typ == "boolean":
+		vdr = "VdrBool"
+		if col.def == "true" || col.def == "false" {
+			opts = append(opts, "Default: " + col.def)
+		}
+	case col.typ == "uuid":
+		vdr = "VdrUID"
+	default:
+		return ""
+	}
+	return "&matilda." + vdr + "{" + strings.Join(opts, ", ") + "}"
+}
+
+func columnSrc(col *column) string {
+	var ctor = "NewCol"
+
+	switch {
+	case col.autoInc == true && col.pkey == true:
+		ctor = "NewColAutoIncPK"
+	case col.autoInc == true:
+		ctor = "NewColAutoInc"
+	case col.pkey == true:
+		ctor = "NewColPK"
+	}
+
+	args := strconv.Quote(col.name)
+	if vdr := validator(col); vdr != "" {
+		args += ", " + vdr
+	}
+	return fmt.Sprintf("matilda.%s(%s).SetTyp(%s)", ctor, args,
+	    strconv.Quote(col.typ))
+}
+
+func generate(pkg string, tables []*table) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by matilda-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import (\n\"database/sql\"\n\n" +
+	    "\"github.com/radixo/matilda\"\n)\n")
+
+	for _, t := range tables {
+		fmt.Fprintf(&b, "\nfunc New%sTable(parent interface{}, " +
+		    "db *sql.DB) *matilda.Table {\n\n", camelCase(t.name))
+		fmt.Fprintf(&b, "return matilda.NewTable(parent, db, %s,\n",
+		    strconv.Quote(t.name))
+		for _, col := range t.columns {
+			fmt.Fprintf(&b, "%s,\n", columnSrc(col))
+		}
+		fmt.Fprintf(&b, ")\n}\n")
+	}
+	return format.Source(b.Bytes())
+}
@@ -1,5 +1,26 @@
 package matilda
 
+// Create a type name for foreign key actions
+type FKAction uint
+const (
+	FK_NO_ACTION FKAction = iota
+	FK_RESTRICT
+	FK_CASCADE
+	FK_SET_NULL
+	FK_SET_DEFAULT
+)
+
+type ForeignKey struct {
+	// Referenced table
+	Table *Table
+
+	// Referenced column name
+	Column string
+
+	// Actions on referenced row delete and update
+	OnDelete, OnUpdate FKAction
+}
+
 type Column struct {
 	// Column name on database
 	Name string
@@ -13,6 +34,9 @@ type Column struct {
 	// Is auto incremental
 	AutoInc bool
 
+	// Referenced column, nil when not a foreign key
+	FKey *ForeignKey
+
 	// FieldValidators
 	Validators []FieldValidator
 }
@@ -50,3 +74,60 @@ func NewColAutoIncPK(name string, vdrs ...FieldValidator) (c *Column) {
 
 	return c
 }
+
+func (c *Column) SetTyp(typ string) *Column {
+
+	c.Typ = typ
+	return c
+}
+
+// Set the column as a foreign key to col on table t
+func (c *Column) SetFKey(t *Table, col string, onDelete,
+    onUpdate FKAction) *Column {
+
+	c.FKey = &ForeignKey{Table: t, Column: col, OnDelete: onDelete,
+	    OnUpdate: onUpdate}
+	return c
+}
+
+// Get NotNull and Default options from a known FieldValidator
+func vdrOptions(vdr FieldValidator) (notNull bool, def interface{}) {
+
+	switch v := vdr.(type) {
+	case *VdrInt64:
+		return v.NotNull, v.Default
+	case *VdrInt32:
+		return v.NotNull, v.Default
+	case *VdrString:
+		return v.NotNull, v.Default
+	case *VdrBool:
+		return v.NotNull, v.Default
+	case *VdrUID:
+		return v.NotNull, v.Default
+	case *VdrEmailAddress:
+		return v.NotNull, nil
+	}
+	return false, nil
+}
+
+// Is NOT NULL when any of the column validators has NotNull set
+func (c *Column) IsNotNull() bool {
+
+	for _, vdr := range c.Validators {
+		if notNull, _ := vdrOptions(vdr); notNull == true {
+			return true
+		}
+	}
+	return false
+}
+
+// Default value from the first column validator having one
+func (c *Column) DefaultValue() interface{} {
+
+	for _, vdr := range c.Validators {
+		if _, def := vdrOptions(vdr); def != nil {
+			return def
+		}
+	}
+	return nil
+}
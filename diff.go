@@ -0,0 +1,147 @@
+package matilda
+
+// Differences between a declared column and its database version
+type ColumnDiff struct {
+	// Declared column
+	Column *Column
+
+	// Column on database
+	DBTyp string
+	DBNotNull bool
+	DBDefault string
+
+	// Changed properties
+	TypChanged bool
+	NotNullChanged bool
+	DefaultChanged bool
+}
+
+// Table constraint as rendered by the schema driver
+type Constraint struct {
+	Name string
+
+	// Definition following CONSTRAINT name
+	Def string
+}
+
+// Differences between a declared table and its database version
+type TableDiff struct {
+	// Declared table
+	Table *Table
+
+	// Table not present on database
+	Missing bool
+
+	// Declared columns not present on database
+	MissingColumns []*Column
+
+	// Database columns not declared
+	ExtraColumns []string
+
+	// Columns present on both with different definitions
+	ChangedColumns []*ColumnDiff
+
+	// Declared indexes not present on database
+	MissingIndexes []*Index
+
+	// Database indexes not declared
+	ExtraIndexes []string
+
+	// Declared indexes with other columns, uniqueness or predicate on
+	// database
+	ChangedIndexes []*Index
+
+	// Declared constraints not present on database
+	MissingConstraints []*Constraint
+
+	// Declared constraints with other definitions on database
+	ChangedConstraints []*Constraint
+
+	// Database constraints not declared
+	ExtraConstraints []string
+
+	// Render drops of the extra columns, indexes and constraints; dropped
+	// columns lose their data
+	DropExtra bool
+}
+
+func (td *TableDiff) IsEmpty() bool {
+
+	return td.Missing == false && len(td.MissingColumns) == 0 &&
+	    len(td.ExtraColumns) == 0 && len(td.ChangedColumns) == 0 &&
+	    len(td.MissingIndexes) == 0 && len(td.ExtraIndexes) == 0 &&
+	    len(td.ChangedIndexes) == 0 && len(td.MissingConstraints) == 0 &&
+	    len(td.ChangedConstraints) == 0 && len(td.ExtraConstraints) == 0
+}
+
+// Statements bringing the database in line with the declared table, extra
+// columns, indexes and constraints are kept unless DropExtra is set
+func (td *TableDiff) SQL() ([]string, error) {
+
+	if td.IsEmpty() == true {
+		return nil, nil
+	}
+	sdrv, err := td.Table.schemaDriver()
+	if err != nil {
+		return nil, err
+	}
+	return sdrv.AlterTable(td)
+}
+
+// Differences between a set of declared tables and the database
+type SchemaDiff struct {
+	// Only tables having differences
+	Tables []*TableDiff
+
+	// Render drops of the extra columns, indexes and constraints of all
+	// tables
+	DropExtra bool
+}
+
+// Compare declared tables with their databases
+func Diff(tables ...*Table) (*SchemaDiff, error) {
+	var sd = new(SchemaDiff)
+
+	for _, t := range tables {
+		td, err := t.Diff()
+		if err != nil {
+			return nil, err
+		}
+		if td.IsEmpty() == false {
+			sd.Tables = append(sd.Tables, td)
+		}
+	}
+	return sd, nil
+}
+
+func (sd *SchemaDiff) IsEmpty() bool {
+
+	return len(sd.Tables) == 0
+}
+
+// Declared tables not present on database
+func (sd *SchemaDiff) MissingTables() (tables []*Table) {
+
+	for _, td := range sd.Tables {
+		if td.Missing == true {
+			tables = append(tables, td.Table)
+		}
+	}
+	return
+}
+
+// Statements bringing the database in line with the declared tables
+func (sd *SchemaDiff) SQL() (stmts []string, err error) {
+
+	for _, td := range sd.Tables {
+		if sd.DropExtra == true {
+			td.DropExtra = true
+		}
+		tstmts, err := td.SQL()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, tstmts...)
+	}
+	return stmts, nil
+}
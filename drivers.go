@@ -1,33 +1,238 @@
 package matilda
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"weak"
 )
 
 // DriverCreator function type
 type DriverCreator func (Entity) Driver
 
+// Capability type, a set of features supported by a driver
+type Capability uint
+
+// Driver capabilities
+const (
+	CAP_RETURNING Capability = 1 << iota
+	CAP_UPSERT
+	CAP_SAVEPOINT
+	CAP_ROW_LOCKING
+	CAP_LISTEN_NOTIFY
+	CAP_COPY
+	CAP_CURSORS
+)
+
+var capabilityNames = []string{
+	"RETURNING",
+	"upsert",
+	"savepoints",
+	"row locking",
+	"LISTEN/NOTIFY",
+	"COPY",
+	"server-side cursors",
+}
+
+func (c Capability) String() string {
+	var names []string
+
+	for i, name := range capabilityNames {
+		if c & (1 << uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// Returned when a feature isn't supported by the driver
+var ErrUnsupported = errors.New("matilda: Feature not supported.")
+
 // Driver interface type
 type Driver interface {
+	// Features supported by the driver and its database
+	Capabilities() Capability
+}
+
+// Drivers supporting CAP_SAVEPOINT, rendering the statements setting,
+// rolling back to and releasing a savepoint; release may be empty
+type SavepointDriver interface {
+	Savepoint(name string) (set, rollback, release string)
+}
+
+// Upsert options, conflicting on primary keys and updating the non key
+// columns present in data by default
+type UpsertOpts struct {
+	// Leave conflicting rows untouched
+	DoNothing bool
+
+	// Columns of the primary key or unique index conflicting
+	Conflict []string
+
+	// Columns updated on conflict
+	Update []string
+
+	// Values of the Update columns, validated for an update apart from
+	// the inserted ones; filled by Table.Upsert, drivers use the inserted
+	// values of columns missing here
+	Values map[string]interface{}
 }
 
 // The default interface for writing a Relational Database Driver
+//
+// The context reaches the database, cancelling statements when done. The
+// Executor is never nil, tables pass their database by default.
 type CRUDDriver interface {
-	Insert(*sql.Tx, map[string]interface{}) error
-	Update(*sql.Tx, map[string]interface{}) error
-	SelectByKey(*sql.Tx, []string, ...interface{}) (map[string]interface{},
+	Insert(context.Context, Executor, map[string]interface{}) error
+	Update(context.Context, Executor, map[string]interface{}) error
+	Upsert(context.Context, Executor, map[string]interface{},
+	    *UpsertOpts) error
+	InsertMany(context.Context, Executor, []map[string]interface{}) error
+	SelectByKey(context.Context, Executor, []string, ...interface{}) (
+	    map[string]interface{}, error)
+	SelectOne(context.Context, Executor, []string, string,
+	    ...interface{}) (map[string]interface{}, error)
+	Select(context.Context, Executor, []string, string, ...interface{}) (
+	    Rows, error)
+	Delete(context.Context, Executor, map[string]interface{}) error
+	UpdateWhere(context.Context, Executor, map[string]interface{}, string,
+	    ...interface{}) (int64, error)
+	DeleteWhere(context.Context, Executor, string, ...interface{}) (int64,
 	    error)
-	SelectOne(*sql.Tx, []string, string, ...interface{}) (
-            map[string]interface{}, error)
-	Select(*sql.Tx, []string, string, ...interface{}) (Rows, error)
-	Delete(*sql.Tx, map[string]interface{}) error
+}
+
+// The default interface for writing a Database Schema Driver
+type SchemaDriver interface {
+	// Statements creating, dropping and emptying the entity
+	CreateTable() ([]string, error)
+	DropTable() (string, error)
+	TruncateTable(...*Table) (string, error)
+	CreateIndex(*Index, bool) (string, error)
+	DropIndex(*Index, bool) (string, error)
+
+	// Compare the entity with the database and render the differences
+	Diff(Executor) (*TableDiff, error)
+	AlterTable(*TableDiff) ([]string, error)
 }
 
 // Map of registered DriverCreators for CRUD
 var crudDrivers = make(map[string]DriverCreator)
 
+// Map of registered DriverCreators for Schema
+var schemaDrivers = make(map[string]DriverCreator)
+
+// Driver names chosen for databases, weak keys let closed databases go
+var dbDrivers = struct {
+	sync.RWMutex
+	m map[weak.Pointer[sql.DB]]string
+}{m: make(map[weak.Pointer[sql.DB]]string)}
+
+// DriverUnwrapper function type, returns nil when drv is not a wrapper
+type DriverUnwrapper func (drv driver.Driver) driver.Driver
+
+// Registered DriverUnwrappers for instrumented and proxy drivers
+var driverUnwrappers = []DriverUnwrapper{unwrapDriver}
+
+// Unwrap drivers having an Unwrap method
+func unwrapDriver(drv driver.Driver) driver.Driver {
+
+	if w, ok := drv.(interface{ Unwrap() driver.Driver }); ok == true {
+		return w.Unwrap()
+	}
+	return nil
+}
+
+// Register a DriverUnwrapper used when a driver is not registered
+func RegisterDriverUnwrapper(u DriverUnwrapper) {
+
+	driverUnwrappers = append(driverUnwrappers, u)
+}
+
+// Use the driver registered as libname for all entities on db, detecting it
+// again when libname is empty
+func SetDBDriver(db *sql.DB, libname string) {
+
+	dbDrivers.Lock()
+	defer dbDrivers.Unlock()
+
+	// Forget collected databases
+	for k := range dbDrivers.m {
+		if k.Value() == nil {
+			delete(dbDrivers.m, k)
+		}
+	}
+	if libname == "" {
+		delete(dbDrivers.m, weak.Make(db))
+		return
+	}
+	dbDrivers.m[weak.Make(db)] = libname
+}
+
+func dbDriverName(db *sql.DB) string {
+
+	dbDrivers.RLock()
+	defer dbDrivers.RUnlock()
+	return dbDrivers.m[weak.Make(db)]
+}
+
+// Entities choosing their driver by name
+type driverNamer interface {
+	GetDriverName() string
+}
+
+// Driver name chosen by an entity, empty when not chosen
+func entityDriverName(e Entity) string {
+
+	if n, ok := e.(driverNamer); ok == true {
+		return n.GetDriverName()
+	}
+	return ""
+}
+
+// Find the DriverCreator for db on registered creators, name is an
+// explicit choice when not empty
+func findDriver(creators map[string]DriverCreator, name string,
+    db *sql.DB) (DriverCreator, error) {
+
+	// Explicit choices, by entity or by database
+	if name == "" {
+		name = dbDriverName(db)
+	}
+	if name != "" {
+		if d, ok := creators[name]; ok == true {
+			return d, nil
+		}
+		return nil, fmt.Errorf("matilda: Driver %q not registered.",
+		    name)
+	}
+
+	// Database driver type, unwrapping known wrappers
+	drv := db.Driver()
+	_loop:
+	for drv != nil {
+		name = reflect.TypeOf(drv).String()
+		if d, ok := creators[name]; ok == true {
+			return d, nil
+		}
+		for _, u := range driverUnwrappers {
+			if inner := u(drv); inner != nil {
+				drv = inner
+				continue _loop
+			}
+		}
+		break
+	}
+	return nil, fmt.Errorf("matilda: Can't find a driver for %q.", name)
+}
+
 // Register a CRUDDriver to be used by CRUD Entities
 func RegisterCRUDDriver(libname string, c DriverCreator) {
 
@@ -37,11 +242,25 @@ func RegisterCRUDDriver(libname string, c DriverCreator) {
 // Get CRUDDriver
 func GetCRUDDriver(e Entity) (drv CRUDDriver, err error) {
 
-	connName := reflect.TypeOf(e.GetDB().Driver()).String()
-	if d, ok := crudDrivers[connName]; ok == true {
-		drv = d(e).(CRUDDriver)
-		return
+	d, err := findDriver(crudDrivers, entityDriverName(e), e.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	return d(e).(CRUDDriver), nil
+}
+
+// Register a SchemaDriver to be used by Entities DDL
+func RegisterSchemaDriver(libname string, c DriverCreator) {
+
+	schemaDrivers[libname] = c
+}
+
+// Get SchemaDriver
+func GetSchemaDriver(e Entity) (drv SchemaDriver, err error) {
+
+	d, err := findDriver(schemaDrivers, entityDriverName(e), e.GetDB())
+	if err != nil {
+		return nil, err
 	}
-	err = errors.New("Can't find a driver.")
-	return
+	return d(e).(SchemaDriver), nil
 }
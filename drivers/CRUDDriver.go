@@ -0,0 +1,643 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/radixo/matilda"
+)
+
+// Smallest number of rows sent through COPY
+const minCopyRows = 1000
+
+// Executors starting transactions, *sql.DB and *sql.Conn
+type txBeginner interface {
+	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+}
+
+// CRUDDriver for SQL databases, database details come from its Dialect
+type SqlCRUDDriver struct {
+	// Entity type
+	etype matilda.EntityType
+
+	// Pointer to table being used by the driver
+	table *matilda.Table
+
+	// Database SQL dialect
+	dialect Dialect
+}
+
+// DriverCreator of SqlCRUDDrivers using dialect
+func NewSqlCRUDDriver(dialect Dialect) matilda.DriverCreator {
+
+	return func(e matilda.Entity) matilda.Driver {
+		var d = new(SqlCRUDDriver)
+
+		d.etype = e.GetType()
+		d.dialect = dialect
+
+		// Stores table reference into the driver instance
+		if d.etype == matilda.ENT_TABLE {
+			d.table = e.(*matilda.Table)
+		}
+		return d
+	}
+}
+
+func (d *SqlCRUDDriver) Capabilities() matilda.Capability {
+
+	return d.dialect.Capabilities()
+}
+
+func (d *SqlCRUDDriver) Savepoint(name string) (set, rollback,
+    release string) {
+
+	return d.dialect.Savepoint(name)
+}
+
+// Convert values scanned from the table columns
+func (d *SqlCRUDDriver) scanned(data map[string]interface{}) {
+
+	for k, v := range data {
+		if col := d.table.GetColumn(k); col != nil {
+			data[k] = d.dialect.ScanValue(col, v)
+		}
+	}
+}
+
+func (d *SqlCRUDDriver) GetDialect() Dialect {
+
+	return d.dialect
+}
+
+// Placeholders of n parameters after the ith one
+func (d *SqlCRUDDriver) params(n int, i *int) (ret []string) {
+
+	for ; n > 0; n-- {
+		*i++
+		ret = append(ret, d.dialect.Placeholder(*i))
+	}
+	return
+}
+
+func (d *SqlCRUDDriver) paramsEqual(cols []string, i *int) (ret []string) {
+
+	for _, col := range cols {
+		*i++
+		ret = append(ret, col + " = " + d.dialect.Placeholder(*i))
+	}
+	return
+}
+
+func (d *SqlCRUDDriver) assureIdentifiers(cols []string) (n, o []string) {
+
+	if cols != nil {
+		goto endAllColumns
+	}
+	// Using all columns
+	for _, scol := range d.table.AllColumns {
+		n = append(n, d.dialect.Quote(scol.Name))
+		o = append(o, scol.Name)
+	}
+	return
+	endAllColumns:
+
+	// Using selected columns
+	for _, col := range cols {
+		n = append(n, d.dialect.Quote(col)) // new
+		o = append(o, col) // old
+	}
+	return
+}
+
+func (d *SqlCRUDDriver) pkeysIdentifiers() (cols []string) {
+
+	for _, col := range d.table.PKeys {
+		cols = append(cols, d.dialect.Quote(col.Name))
+	}
+	return
+}
+
+// Report if cols are the primary key columns, in any order
+func (d *SqlCRUDDriver) isPKeys(cols []string) bool {
+
+	if len(cols) != len(d.table.PKeys) {
+		return false
+	}
+	_loop:
+	for _, col := range d.table.PKeys {
+		for _, name := range cols {
+			if name == col.Name {
+				continue _loop
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (d *SqlCRUDDriver) assureVals(vals []interface{}) []interface{} {
+
+	for i := range vals {
+		vals[i] = d.dialect.Value(vals[i])
+	}
+	return vals
+}
+
+func (d *SqlCRUDDriver) assureCols(ref []*matilda.Column,
+    data map[string]interface{}) (cols []string, vals []interface{}) {
+
+	for _, col := range ref {
+		if val, ok := data[col.Name]; ok == true {
+			cols = append(cols, d.dialect.Quote(col.Name))
+			vals = append(vals, d.dialect.Value(val))
+		}
+	}
+
+	return
+}
+
+func (d *SqlCRUDDriver) assureColumns(data map[string]interface{}) (
+    []string, []interface{}) {
+
+	return d.assureCols(d.table.Columns, data);
+}
+
+func (d *SqlCRUDDriver) assurePKeys(data map[string]interface{}) (
+    []string, []interface{}) {
+
+	return d.assureCols(d.table.PKeys, data);
+}
+
+// Columns to insert and columns filled by the database
+//
+// Columns left out of data and nil auto incremental ones are filled by the
+// database, other nil values are inserted as NULL.
+func (d *SqlCRUDDriver) insertColumns(data map[string]interface{}) (
+    cols []string, vals []interface{}, ret []string) {
+
+	for _, col := range d.table.AllColumns {
+		val, ok := data[col.Name]
+		if ok == false || (val == nil && col.AutoInc == true) {
+			// Auto incremental or database default
+			ret = append(ret, col.Name)
+			continue
+		}
+		cols = append(cols, d.dialect.Quote(col.Name))
+		vals = append(vals, d.dialect.Value(val))
+	}
+	return
+}
+
+// Insert data with an optional conflict clause, taking c_vals after the
+// inserted values
+func (d *SqlCRUDDriver) insert(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}, conflict string,
+    c_vals ...interface{}) error {
+	var err error
+	var res sql.Result
+	var row *sql.Row
+
+	i := new(int)
+	cols, vals, ret := d.insertColumns(data)
+	vals = append(vals, c_vals...)
+	table := d.dialect.Quote(d.table.Name)
+	params := d.params(len(cols), i)
+	returning := d.dialect.Capabilities() & matilda.CAP_RETURNING
+	if returning == 0 || len(ret) == 0 {
+		sql := d.dialect.Insert(table, cols, params, conflict, nil)
+		res, err = ex.ExecContext(ctx, sql, vals...)
+		if err != nil {
+			return err
+		}
+		if conflict != "" {
+			d.upsertResult(res, data)
+			return nil
+		}
+		SqlProcessExecResult(res, d.table, data)
+		return nil
+	}
+
+	// Read back auto incremental and default values
+	a_ret, _ := d.assureIdentifiers(ret)
+	sql := d.dialect.Insert(table, cols, params, conflict, a_ret)
+	row = ex.QueryRowContext(ctx, sql, vals...)
+	rdata, err := SqlProcessQueryRowResult(row, d.table, ret)
+	if err != nil {
+		return err
+	}
+	if rdata == nil {
+		// Conflicting row left untouched
+		data[matilda.RES_ROWSAFFECTED] = int64(0)
+		return nil
+	}
+	d.scanned(rdata)
+	for k, v := range rdata {
+		data[k] = v
+	}
+	if col := getAutoIncColumn(d.table); col != nil {
+		data[matilda.RES_AUTOINC] = data[col.Name]
+	}
+	data[matilda.RES_ROWSAFFECTED] = int64(1)
+	return nil
+}
+
+// Process the result of an upsert without RETURNING; the insert id is
+// stale when the conflicting row was updated, so it is only kept for an
+// insert, one affected row, of a row missing its key
+func (d *SqlCRUDDriver) upsertResult(res sql.Result,
+    data map[string]interface{}) {
+	var r = make(map[string]interface{})
+
+	SqlProcessExecResult(res, d.table, r)
+	if n, ok := r[matilda.RES_ROWSAFFECTED]; ok == true {
+		data[matilda.RES_ROWSAFFECTED] = n
+	}
+	col := getAutoIncColumn(d.table)
+	if col == nil || data[col.Name] != nil ||
+	    r[matilda.RES_ROWSAFFECTED] != int64(1) {
+		return
+	}
+	if id, ok := r[matilda.RES_AUTOINC]; ok == true {
+		data[col.Name] = id
+		data[matilda.RES_AUTOINC] = id
+	}
+}
+
+func (d *SqlCRUDDriver) Insert(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}) error {
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		if err := d.insert(ctx, ex, data, ""); err != nil {
+			return fmt.Errorf("matilda driver Insert: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+// Insert rows sharing cols, in multi-row statements or a COPY
+func (d *SqlCRUDDriver) insertBatch(ctx context.Context, ex matilda.Executor,
+    cols []string, rows []map[string]interface{}) error {
+	var err error
+
+	// Nothing to batch when all values come from the database
+	if len(cols) == 0 {
+		for _, data := range rows {
+			if err = d.insert(ctx, ex, data, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	table := d.dialect.Quote(d.table.Name)
+	c_sql := d.dialect.CopyFrom(table, cols)
+	if c_sql != "" && len(rows) >= minCopyRows {
+		return d.copyFrom(ctx, ex, c_sql, rows)
+	}
+
+	per := d.dialect.MaxParams() / len(cols)
+	if max := d.dialect.MaxRows(); max > 0 && per > max {
+		per = max
+	}
+	if per == 0 {
+		per = 1
+	}
+	for len(rows) > 0 {
+		var params [][]string
+		var vals []interface{}
+
+		n := len(rows)
+		if n > per {
+			n = per
+		}
+		i := new(int)
+		for _, data := range rows[:n] {
+			_, r_vals, _ := d.insertColumns(data)
+			params = append(params, d.params(len(cols), i))
+			vals = append(vals, r_vals...)
+		}
+		sql := d.dialect.InsertMany(table, cols, params)
+		_, err = ex.ExecContext(ctx, sql, vals...)
+		if err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// Stream rows to a COPY statement, inside a transaction
+func (d *SqlCRUDDriver) copyFrom(ctx context.Context, ex matilda.Executor,
+    c_sql string, rows []map[string]interface{}) (err error) {
+
+	tx, ok := ex.(*sql.Tx)
+	if ok == false {
+		return errors.New("COPY needs a transaction.")
+	}
+
+	stmt, err := tx.PrepareContext(ctx, c_sql)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, data := range rows {
+		_, vals, _ := d.insertColumns(data)
+		if _, err = stmt.ExecContext(ctx, vals...); err != nil {
+			return err
+		}
+	}
+	// Flush buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// Insert rows all or none, in a transaction begun on ex unless it is one
+func (d *SqlCRUDDriver) insertMany(ctx context.Context, ex matilda.Executor,
+    rows []map[string]interface{}) (err error) {
+	var tx *sql.Tx
+
+	b, ok := ex.(txBeginner)
+	if _, is := ex.(*sql.Tx); is == false && ok == true {
+		if tx, err = b.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+		ex = tx
+	}
+
+	for len(rows) > 0 {
+		// Consecutive rows inserting the same columns
+		cols, _, _ := d.insertColumns(rows[0])
+		key := strings.Join(cols, ",")
+		n := 1
+		for ; n < len(rows); n++ {
+			n_cols, _, _ := d.insertColumns(rows[n])
+			if strings.Join(n_cols, ",") != key {
+				break
+			}
+		}
+		if err = d.insertBatch(ctx, ex, cols, rows[:n]); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// Insert many rows, generated values are not read back
+func (d *SqlCRUDDriver) InsertMany(ctx context.Context, ex matilda.Executor,
+    rows []map[string]interface{}) error {
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		if err := d.insertMany(ctx, ex, rows); err != nil {
+			return fmt.Errorf("matilda driver InsertMany: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (d *SqlCRUDDriver) Upsert(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}, opts *matilda.UpsertOpts) error {
+	var conflict, update []string
+	var u_vals []interface{}
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		for _, col := range opts.Conflict {
+			conflict = append(conflict, d.dialect.Quote(col))
+		}
+		for _, col := range opts.Update {
+			update = append(update, d.dialect.Quote(col))
+			val, ok := opts.Values[col]
+			if ok == false {
+				val = data[col]
+			}
+			u_vals = append(u_vals, d.dialect.Value(val))
+		}
+
+		// Update params follow the inserted ones
+		cols, _, _ := d.insertColumns(data)
+		i := new(int)
+		*i = len(cols)
+		clause := d.dialect.OnConflict(conflict, update,
+		    d.params(len(update), i), d.isPKeys(opts.Conflict))
+		if clause == "" {
+			return matilda.ErrUnsupported
+		}
+		err := d.insert(ctx, ex, data, clause, u_vals...)
+		if err != nil {
+			return fmt.Errorf("matilda driver Upsert: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (d *SqlCRUDDriver) Update(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}) error {
+	var err error
+	var res sql.Result
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		i := new(int)
+		cols, vals := d.assureColumns(data)
+		p_cols, p_vals := d.assurePKeys(data)
+		sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s;",
+		    d.dialect.Quote(d.table.Name),
+		    strings.Join(d.paramsEqual(cols, i), ","),
+		    strings.Join(d.paramsEqual(p_cols, i), " AND "))
+		res, err = ex.ExecContext(ctx, sql, append(vals, p_vals...)...)
+		if err != nil {
+			return fmt.Errorf("matilda driver Update: %w", err)
+		}
+		SqlProcessExecResult(res, d.table, data)
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (d *SqlCRUDDriver) SelectByKey(ctx context.Context, ex matilda.Executor,
+    cols []string, keys ...interface{}) (map[string]interface{}, error) {
+	var row *sql.Row
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		i := new(int)
+		a_cols, _cols := d.assureIdentifiers(cols)
+		p_cols := d.pkeysIdentifiers()
+		sql := d.dialect.Select(d.dialect.Quote(d.table.Name), a_cols,
+		    strings.Join(d.paramsEqual(p_cols, i), " AND "), 0)
+
+		row = ex.QueryRowContext(ctx, sql, d.assureVals(keys)...)
+
+		ret, err := SqlProcessQueryRowResult(row, d.table, _cols)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver SelectByKey: %w",
+			    err)
+		}
+		d.scanned(ret)
+		return ret, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (d *SqlCRUDDriver) SelectOne(ctx context.Context, ex matilda.Executor,
+    cols []string, filter string, params ...interface{}) (
+    map[string]interface{}, error) {
+	var row *sql.Row
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		a_cols, _cols := d.assureIdentifiers(cols)
+		sql := d.dialect.Select(d.dialect.Quote(d.table.Name), a_cols,
+		    filter, 1)
+
+		row = ex.QueryRowContext(ctx, sql, d.assureVals(params)...)
+
+		ret, err := SqlProcessQueryRowResult(row, d.table, _cols)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver SelectOne: %w",
+			    err)
+		}
+		d.scanned(ret)
+		return ret, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (d *SqlCRUDDriver) Select(ctx context.Context, ex matilda.Executor,
+    cols []string, filter string, params ...interface{}) (matilda.Rows,
+    error) {
+	var err error
+	var rows *sql.Rows
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		a_cols, _cols := d.assureIdentifiers(cols)
+		sql := d.dialect.Select(d.dialect.Quote(d.table.Name), a_cols,
+		    filter, 0)
+		rows, err = ex.QueryContext(ctx, sql, d.assureVals(params)...)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver Select: %w", err)
+		}
+		ret := SqlProcessQueryResult(rows, d.table, _cols)
+		ret.(*sqlRows).scanned = d.scanned
+		return ret, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (d *SqlCRUDDriver) Delete(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}) error {
+	var err error
+	var res sql.Result
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		i := new(int)
+		p_cols, p_vals := d.assurePKeys(data)
+		sql := fmt.Sprintf("DELETE FROM %s WHERE %s;",
+		    d.dialect.Quote(d.table.Name),
+		    strings.Join(d.paramsEqual(p_cols, i), " AND "))
+		res, err = ex.ExecContext(ctx, sql, p_vals...)
+		if err != nil {
+			return fmt.Errorf("matilda driver Delete: %w", err)
+		}
+		SqlProcessExecResult(res, d.table, data)
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+// Placeholders carry their position, parameters may be in any order
+func (d *SqlCRUDDriver) numbered() bool {
+
+	return d.dialect.Placeholder(1) != d.dialect.Placeholder(2)
+}
+
+// Set placeholders are numbered after filter params, so filters are written
+// as in Select
+func (d *SqlCRUDDriver) UpdateWhere(ctx context.Context, ex matilda.Executor,
+    set map[string]interface{}, filter string, params ...interface{}) (
+    int64, error) {
+	var err error
+	var res sql.Result
+	var args []interface{}
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		i := new(int)
+		*i = len(params)
+		cols, vals := d.assureColumns(set)
+		sql := fmt.Sprintf("UPDATE %s SET %s",
+		    d.dialect.Quote(d.table.Name),
+		    strings.Join(d.paramsEqual(cols, i), ","))
+		if filter != "" {
+			sql += " WHERE " + filter
+		}
+		sql += ";"
+		if d.numbered() == true {
+			args = append(d.assureVals(params), vals...)
+		} else {
+			args = append(vals, d.assureVals(params)...)
+		}
+		res, err = ex.ExecContext(ctx, sql, args...)
+		if err == nil {
+			return res.RowsAffected()
+		}
+		return 0, fmt.Errorf("matilda driver UpdateWhere: %w", err)
+	default:
+		return 0, errors.New("Entity type not implemented.")
+	}
+}
+
+// Delete the rows matching filter, all rows when it is empty
+func (d *SqlCRUDDriver) DeleteWhere(ctx context.Context, ex matilda.Executor,
+    filter string, params ...interface{}) (int64, error) {
+	var err error
+	var res sql.Result
+
+	switch d.etype {
+	case matilda.ENT_TABLE:
+		sql := "DELETE FROM " + d.dialect.Quote(d.table.Name)
+		if filter != "" {
+			sql += " WHERE " + filter
+		}
+		sql += ";"
+		res, err = ex.ExecContext(ctx, sql, d.assureVals(params)...)
+		if err == nil {
+			return res.RowsAffected()
+		}
+		return 0, fmt.Errorf("matilda driver DeleteWhere: %w", err)
+	default:
+		return 0, errors.New("Entity type not implemented.")
+	}
+}
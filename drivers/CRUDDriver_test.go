@@ -0,0 +1,101 @@
+package drivers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+// Dialect reading values back through RETURNING
+type returningDialect struct {
+	BaseDialect
+}
+
+func (d returningDialect) Capabilities() matilda.Capability {
+
+	return matilda.CAP_RETURNING
+}
+
+func TestInsertColumns(t *testing.T) {
+
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("name", &matilda.VdrString{NotNull: true}),
+	    matilda.NewCol("status", &matilda.VdrString{Default: "new"}),
+	    matilda.NewCol("created", &matilda.VdrInt64{}),
+	    matilda.NewCol("note"))
+	d := NewSqlCRUDDriver(returningDialect{})(tb).(*SqlCRUDDriver)
+
+	// Validators ran, "created" was left out and "note" given as NULL
+	data := map[string]interface{}{"id": nil, "name": "a",
+	    "status": "new", "note": nil}
+	cols, vals, ret := d.insertColumns(data)
+	w_cols := []string{`"name"`, `"status"`, `"note"`}
+	if reflect.DeepEqual(cols, w_cols) == false {
+		t.Errorf("got columns %v, want %v", cols, w_cols)
+	}
+	w_vals := []interface{}{"a", "new", nil}
+	if reflect.DeepEqual(vals, w_vals) == false {
+		t.Errorf("got values %v, want %v", vals, w_vals)
+	}
+	w_ret := []string{"id", "created"}
+	if reflect.DeepEqual(ret, w_ret) == false {
+		t.Errorf("got returning %v, want %v", ret, w_ret)
+	}
+
+	a_ret, _ := d.assureIdentifiers(ret)
+	sql := d.dialect.Insert(`"items"`, cols, d.params(len(cols), new(int)),
+	    "", a_ret)
+	want := `INSERT INTO "items"("name","status","note")VALUES(?,?,?) ` +
+	    `RETURNING "id","created";`
+	if sql != want {
+		t.Errorf("got %s, want %s", sql, want)
+	}
+}
+
+// Result of a MySQL upsert
+type upsertResult struct {
+	id, rows int64
+}
+
+func (r upsertResult) LastInsertId() (int64, error) {
+
+	return r.id, nil
+}
+
+func (r upsertResult) RowsAffected() (int64, error) {
+
+	return r.rows, nil
+}
+
+func TestUpsertResult(t *testing.T) {
+	var tests = []struct {
+		data map[string]interface{}
+		res upsertResult
+		id interface{}
+	}{
+		{map[string]interface{}{"name": "a"}, upsertResult{5, 1},
+		    int64(5)},
+		{map[string]interface{}{"name": "a"}, upsertResult{5, 2}, nil},
+		{map[string]interface{}{"name": "a"}, upsertResult{5, 0}, nil},
+		{map[string]interface{}{"id": 1, "name": "a"},
+		    upsertResult{5, 1}, 1},
+	}
+
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("name", &matilda.VdrString{}))
+	d := NewSqlCRUDDriver(BaseDialect{})(tb).(*SqlCRUDDriver)
+	for i, tt := range tests {
+		d.upsertResult(tt.res, tt.data)
+		if tt.data["id"] != tt.id {
+			t.Errorf("%d: got id %v, want %v", i, tt.data["id"],
+			    tt.id)
+		}
+		if tt.data[matilda.RES_ROWSAFFECTED] != tt.res.rows {
+			t.Errorf("%d: got %v rows affected", i,
+			    tt.data[matilda.RES_ROWSAFFECTED])
+		}
+	}
+}
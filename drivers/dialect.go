@@ -0,0 +1,200 @@
+package drivers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/radixo/matilda"
+)
+
+// SQL details of a database, used by SqlCRUDDriver
+//
+// Statement builders receive quoted identifiers and placeholders.
+type Dialect interface {
+	// Quote an identifier
+	Quote(string) string
+
+	// Placeholder of the nth parameter, starting from 1
+	Placeholder(int) string
+
+	// Convert a value to a type accepted by the database library
+	Value(interface{}) interface{}
+
+	// Convert a value scanned from col
+	ScanValue(col *matilda.Column, val interface{}) interface{}
+
+	// Column type on database, empty when unknown
+	ColumnType(*matilda.Column) string
+
+	// Features of the database, CAP_RETURNING for INSERT ... RETURNING
+	Capabilities() matilda.Capability
+
+	// INSERT statement returning ret columns, conflict is an optional
+	// OnConflict clause
+	Insert(table string, cols, params []string, conflict string,
+	    ret []string) string
+
+	// INSERT statement of many rows of params
+	InsertMany(table string, cols []string, rows [][]string) string
+
+	// Largest number of parameters of a statement, and of rows of an
+	// InsertMany when limited
+	MaxParams() int
+	MaxRows() int
+
+	// COPY statement reading cols from STDIN, empty when not supported
+	CopyFrom(table string, cols []string) string
+
+	// SELECT statement, filter and limit are optional
+	Select(table string, cols []string, filter string, limit int) string
+
+	// Statements setting, rolling back to and releasing a savepoint
+	Savepoint(name string) (set, rollback, release string)
+
+	// Clause turning an INSERT into an upsert setting update columns to
+	// params, DO NOTHING when update is empty; pkey reports conflict is
+	// the primary key. Empty when upserts on conflict are not supported
+	OnConflict(conflict, update, params []string, pkey bool) string
+}
+
+// Standard SQL dialect, embedded by database dialects
+type BaseDialect struct {
+}
+
+func (b BaseDialect) Quote(s string) string {
+	var n = len(s)
+
+	if n > 2 && s[0] == '"' && s[n-1] == '"' {
+		return s
+	}
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+func (b BaseDialect) Placeholder(n int) string {
+
+	return "?"
+}
+
+func (b BaseDialect) Value(val interface{}) interface{} {
+
+	switch v := val.(type) {
+	case matilda.UID:
+		return v.String()
+	default:
+		return val
+	}
+}
+
+func (b BaseDialect) ScanValue(col *matilda.Column,
+    val interface{}) interface{} {
+
+	return val
+}
+
+func (b BaseDialect) ColumnType(col *matilda.Column) string {
+
+	if col.Typ != "" {
+		return col.Typ
+	}
+	for _, vdr := range col.Validators {
+		switch v := vdr.(type) {
+		case *matilda.VdrInt64:
+			return "bigint"
+		case *matilda.VdrInt32:
+			return "integer"
+		case *matilda.VdrString:
+			// Password hashes don't keep the validated length
+			if v.MaxLen > 0 && v.Password == false {
+				return fmt.Sprintf("varchar(%d)", v.MaxLen)
+			}
+			return "text"
+		case *matilda.VdrBool:
+			return "boolean"
+		case *matilda.VdrUID:
+			return "char(32)"
+		case *matilda.VdrEmailAddress:
+			return "text"
+		}
+	}
+	if col.AutoInc == true {
+		return "bigint"
+	}
+	return ""
+}
+
+func (b BaseDialect) Capabilities() matilda.Capability {
+
+	return matilda.CAP_SAVEPOINT
+}
+
+func (b BaseDialect) Insert(table string, cols, params []string,
+    conflict string, ret []string) string {
+
+	sql := "INSERT INTO " + table
+	if len(cols) == 0 {
+		sql += " DEFAULT VALUES"
+	} else {
+		sql += fmt.Sprintf("(%s)VALUES(%s)", strings.Join(cols, ","),
+		    strings.Join(params, ","))
+	}
+	if conflict != "" {
+		sql += " " + conflict
+	}
+	if len(ret) > 0 {
+		sql += " RETURNING " + strings.Join(ret, ",")
+	}
+	return sql + ";"
+}
+
+func (b BaseDialect) InsertMany(table string, cols []string,
+    rows [][]string) string {
+	var values []string
+
+	for _, params := range rows {
+		values = append(values, "(" + strings.Join(params, ",") + ")")
+	}
+	return fmt.Sprintf("INSERT INTO %s(%s)VALUES%s;", table,
+	    strings.Join(cols, ","), strings.Join(values, ","))
+}
+
+// SQLite's default limit, the lowest
+func (b BaseDialect) MaxParams() int {
+
+	return 999
+}
+
+func (b BaseDialect) MaxRows() int {
+
+	return 0
+}
+
+func (b BaseDialect) CopyFrom(table string, cols []string) string {
+
+	return ""
+}
+
+func (b BaseDialect) Select(table string, cols []string, filter string,
+    limit int) string {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(cols, ","), table)
+	if filter != "" {
+		sql += " WHERE " + filter
+	}
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return sql + ";"
+}
+
+func (b BaseDialect) Savepoint(name string) (set, rollback,
+    release string) {
+
+	return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name,
+	    "RELEASE SAVEPOINT " + name
+}
+
+func (b BaseDialect) OnConflict(conflict, update, params []string,
+    pkey bool) string {
+
+	return ""
+}
@@ -0,0 +1,249 @@
+package memory
+
+import (
+	"fmt"
+	"context"
+	"errors"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+func NewCRUDDriver(e matilda.Entity) matilda.Driver {
+	var d = new(MemCRUDDriver)
+
+	d.etype = e.GetType()
+
+	// Stores table reference into the driver instance
+	if d.etype == matilda.ENT_TABLE {
+		d.table = e.(*matilda.Table)
+	}
+	return d
+}
+
+func (m *MemCRUDDriver) Capabilities() matilda.Capability {
+
+	return matilda.CAP_UPSERT | matilda.CAP_SAVEPOINT
+}
+
+func (m *MemCRUDDriver) Savepoint(name string) (set, rollback,
+    release string) {
+
+	return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name,
+	    "RELEASE SAVEPOINT " + name
+}
+
+// Selected columns, all when nil
+func (m *MemCRUDDriver) columns(cols []string) []string {
+
+	if cols != nil {
+		return cols
+	}
+	for _, col := range m.table.AllColumns {
+		cols = append(cols, col.Name)
+	}
+	return cols
+}
+
+func (m *MemCRUDDriver) exec(ctx context.Context, ex matilda.Executor,
+    op string, cmd *command) error {
+
+	res, err := ex.ExecContext(ctx, op, cmd)
+	if err != nil {
+		return err
+	}
+	if cmd.data != nil {
+		drivers.SqlProcessExecResult(res, m.table, cmd.data)
+	}
+	return nil
+}
+
+func (m *MemCRUDDriver) queryRow(ctx context.Context, ex matilda.Executor,
+    op string, cmd *command) (map[string]interface{}, error) {
+
+	row := ex.QueryRowContext(ctx, op, cmd)
+	return drivers.SqlProcessQueryRowResult(row, m.table, cmd.cols)
+}
+
+func (m *MemCRUDDriver) Insert(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}) error {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, data: data}
+		if err := m.exec(ctx, ex, "insert", cmd); err != nil {
+			return fmt.Errorf("matilda driver Insert: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (m *MemCRUDDriver) Update(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}) error {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, data: data}
+		if err := m.exec(ctx, ex, "update", cmd); err != nil {
+			return fmt.Errorf("matilda driver Update: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (m *MemCRUDDriver) InsertMany(ctx context.Context, ex matilda.Executor,
+    rows []map[string]interface{}) error {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, rows: rows}
+		if err := m.exec(ctx, ex, "insert_many", cmd); err != nil {
+			return fmt.Errorf("matilda driver InsertMany: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (m *MemCRUDDriver) Upsert(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}, opts *matilda.UpsertOpts) error {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, data: data, upsert: opts}
+		if err := m.exec(ctx, ex, "upsert", cmd); err != nil {
+			return fmt.Errorf("matilda driver Upsert: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+func (m *MemCRUDDriver) SelectByKey(ctx context.Context, ex matilda.Executor,
+    cols []string, keys ...interface{}) (map[string]interface{}, error) {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, cols: m.columns(cols),
+		    keys: keys}
+		ret, err := m.queryRow(ctx, ex, "select_key", cmd)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver SelectByKey: %w",
+			    err)
+		}
+		return ret, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (m *MemCRUDDriver) SelectOne(ctx context.Context, ex matilda.Executor,
+    cols []string, filter string, params ...interface{}) (
+    map[string]interface{}, error) {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, cols: m.columns(cols),
+		    filter: filter, params: params}
+		ret, err := m.queryRow(ctx, ex, "select", cmd)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver SelectOne: %w",
+			    err)
+		}
+		return ret, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (m *MemCRUDDriver) Select(ctx context.Context, ex matilda.Executor,
+    cols []string, filter string, params ...interface{}) (matilda.Rows,
+    error) {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, cols: m.columns(cols),
+		    filter: filter, params: params}
+		rows, err := ex.QueryContext(ctx, "select", cmd)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver Select: %w", err)
+		}
+		return drivers.SqlProcessQueryResult(rows, m.table, cmd.cols),
+		    nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (m *MemCRUDDriver) Delete(ctx context.Context, ex matilda.Executor,
+    data map[string]interface{}) error {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, data: data}
+		if err := m.exec(ctx, ex, "delete", cmd); err != nil {
+			return fmt.Errorf("matilda driver Delete: %w", err)
+		}
+	default:
+		return errors.New("Entity type not implemented.")
+	}
+
+	return nil
+}
+
+// Exec a command changing rows by filter
+func (m *MemCRUDDriver) execWhere(ctx context.Context, ex matilda.Executor,
+    op string, cmd *command) (int64, error) {
+
+	res, err := ex.ExecContext(ctx, op, cmd)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (m *MemCRUDDriver) UpdateWhere(ctx context.Context, ex matilda.Executor,
+    set map[string]interface{}, filter string, params ...interface{}) (
+    int64, error) {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, data: set, filter: filter,
+		    params: params}
+		n, err := m.execWhere(ctx, ex, "update_where", cmd)
+		if err != nil {
+			return 0, fmt.Errorf("matilda driver UpdateWhere: %w",
+			    err)
+		}
+		return n, nil
+	default:
+		return 0, errors.New("Entity type not implemented.")
+	}
+}
+
+func (m *MemCRUDDriver) DeleteWhere(ctx context.Context, ex matilda.Executor,
+    filter string, params ...interface{}) (int64, error) {
+
+	switch m.etype {
+	case matilda.ENT_TABLE:
+		cmd := &command{table: m.table, filter: filter, params: params}
+		n, err := m.execWhere(ctx, ex, "delete_where", cmd)
+		if err != nil {
+			return 0, fmt.Errorf("matilda driver DeleteWhere: %w",
+			    err)
+		}
+		return n, nil
+	default:
+		return 0, errors.New("Entity type not implemented.")
+	}
+}
@@ -0,0 +1,301 @@
+// Package memory is a matilda backend keeping tables in Go memory, meant for
+// unit tests.
+//
+// Databases are opened through database/sql and share data by name:
+//
+//	db, _ := sql.Open("matilda-memory", "test")
+//
+// Tables are created on first use. Writes inside a transaction are visible
+// to other connections right away and undone on rollback, whatever the
+// isolation level; values written again by other connections are kept.
+package memory
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Name used on sql.Open
+const DriverName = "matilda-memory"
+
+// Databases by name
+var stores = struct {
+	sync.Mutex
+	m map[string]*store
+}{m: make(map[string]*store)}
+
+func getStore(name string) *store {
+
+	stores.Lock()
+	defer stores.Unlock()
+	s, ok := stores.m[name]
+	if ok == false {
+		s = newStore()
+		stores.m[name] = s
+	}
+	return s
+}
+
+// Remove all data of the named database
+func Reset(name string) {
+
+	stores.Lock()
+	delete(stores.m, name)
+	stores.Unlock()
+}
+
+type Driver struct {
+}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+
+	return &conn{store: getStore(name)}, nil
+}
+
+type conn struct {
+	store *store
+
+	// Current transaction
+	tx *tx
+}
+
+type tx struct {
+	conn *conn
+
+	// Functions undoing the transaction writes
+	undo []func()
+
+	// Savepoints set, in order
+	savepoints []savepoint
+
+	// Writes are refused
+	readOnly bool
+}
+
+type savepoint struct {
+	name string
+
+	// Undo functions recorded before the savepoint
+	undo int
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// Isolation levels are accepted and behave alike, read-only transactions
+// refuse writes
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (
+    driver.Tx, error) {
+
+	if c.tx != nil {
+		return nil, errors.New("matilda memory: Transaction already " +
+		    "started.")
+	}
+	c.tx = &tx{conn: c, readOnly: opts.ReadOnly}
+	return c.tx, nil
+}
+
+// Accept any value, commands carry Go values
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+
+	return nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string,
+    args []driver.NamedValue) (driver.Result, error) {
+
+	if len(args) == 0 && strings.Contains(query, "SAVEPOINT") == true {
+		return c.savepoint(query)
+	}
+	cmd, err := commandArg(args)
+	if err != nil {
+		return nil, err
+	}
+	if c.tx != nil && c.tx.readOnly == true {
+		return nil, errors.New("matilda memory: Transaction is " +
+		    "read-only.")
+	}
+	return c.store.exec(c.tx, query, cmd)
+}
+
+// Run SAVEPOINT, ROLLBACK TO SAVEPOINT and RELEASE SAVEPOINT statements
+func (c *conn) savepoint(query string) (driver.Result, error) {
+
+	f := strings.Fields(strings.TrimSuffix(query, ";"))
+	if c.tx == nil || len(f) < 2 {
+		return nil, errors.New("matilda memory: Savepoints need a " +
+		    "transaction.")
+	}
+	name, stmt := f[len(f) - 1], strings.Join(f[:len(f) - 1], " ")
+	if stmt == "SAVEPOINT" {
+		c.tx.savepoints = append(c.tx.savepoints,
+		    savepoint{name: name, undo: len(c.tx.undo)})
+		return driver.ResultNoRows, nil
+	}
+
+	i := len(c.tx.savepoints) - 1
+	for i >= 0 && c.tx.savepoints[i].name != name {
+		i--
+	}
+	if i < 0 {
+		return nil, errors.New("matilda memory: Savepoint " + name +
+		    " not found.")
+	}
+	switch stmt {
+	case "ROLLBACK TO SAVEPOINT":
+		c.tx.rollbackTo(c.tx.savepoints[i].undo)
+		c.tx.savepoints = c.tx.savepoints[:i + 1]
+	case "RELEASE SAVEPOINT":
+		c.tx.savepoints = c.tx.savepoints[:i]
+	default:
+		return nil, errors.New("matilda memory: Unknown statement " +
+		    stmt + ".")
+	}
+	return driver.ResultNoRows, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string,
+    args []driver.NamedValue) (driver.Rows, error) {
+
+	cmd, err := commandArg(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.store.query(query, cmd)
+}
+
+func commandArg(args []driver.NamedValue) (*command, error) {
+
+	if len(args) == 1 {
+		if cmd, ok := args[0].Value.(*command); ok == true {
+			return cmd, nil
+		}
+	}
+	return nil, errors.New("matilda memory: Only matilda commands are " +
+	    "supported.")
+}
+
+func (t *tx) Commit() error {
+
+	t.conn.tx = nil
+	return nil
+}
+
+func (t *tx) Rollback() error {
+
+	t.rollbackTo(0)
+	t.conn.tx = nil
+	return nil
+}
+
+// Undo the writes recorded after the first n
+func (t *tx) rollbackTo(n int) {
+
+	t.conn.store.Lock()
+	for i := len(t.undo) - 1; i >= n; i-- {
+		t.undo[i]()
+	}
+	t.conn.store.Unlock()
+	t.undo = t.undo[:n]
+}
+
+type stmt struct {
+	conn *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+
+	return nil, errors.New("matilda memory: Use ExecContext.")
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+
+	return nil, errors.New("matilda memory: Use QueryContext.")
+}
+
+func (s *stmt) ExecContext(ctx context.Context,
+    args []driver.NamedValue) (driver.Result, error) {
+
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context,
+    args []driver.NamedValue) (driver.Rows, error) {
+
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+type result struct {
+	lastInsertId int64
+	hasInsertId bool
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+
+	if r.hasInsertId == false {
+		return 0, errors.New("matilda memory: No auto incremental " +
+		    "column.")
+	}
+	return r.lastInsertId, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+
+	return r.rowsAffected, nil
+}
+
+type rows struct {
+	cols []string
+	data []map[string]interface{}
+	pos int
+}
+
+func (r *rows) Columns() []string {
+
+	return r.cols
+}
+
+func (r *rows) Close() error {
+
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	for i, col := range r.cols {
+		dest[i] = r.data[r.pos][col]
+	}
+	r.pos++
+	return nil
+}
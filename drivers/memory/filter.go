@@ -0,0 +1,503 @@
+package memory
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/radixo/matilda"
+)
+
+// Filters support a subset of SQL: AND, OR, NOT, parentheses, comparisons
+// (=, <>, !=, <, <=, >, >=), IS [NOT] NULL, [NOT] LIKE and [NOT] IN, over
+// columns, literals and $n or ? placeholders.
+
+type predicate func(map[string]interface{}) bool
+type operand func(map[string]interface{}) interface{}
+
+type token struct {
+	// One of: ident, string, number, param, op, word, end
+	kind string
+	text string
+}
+
+type filterParser struct {
+	table *matilda.Table
+	params []interface{}
+	tokens []token
+	pos int
+
+	// Next ? placeholder
+	nextParam int
+}
+
+var filterWords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IS": true, "NULL": true,
+	"LIKE": true, "IN": true, "TRUE": true, "FALSE": true,
+}
+
+func tokenize(s string) (tokens []token, err error) {
+	var i int
+
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s); j++ {
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						b.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("matilda memory: " +
+				    "Unterminated string in filter.")
+			}
+			tokens = append(tokens, token{"string", b.String()})
+			i = j + 1
+		case c == '"' || c == '`':
+			j := strings.IndexByte(s[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("matilda memory: " +
+				    "Unterminated identifier in filter.")
+			}
+			tokens = append(tokens, token{"ident", s[i+1 : i+1+j]})
+			i += j + 2
+		case c == '?':
+			tokens = append(tokens, token{"param", ""})
+			i++
+		case c == '$':
+			j := i + 1
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, token{"param", s[i+1 : j]})
+			i = j
+		case c >= '0' && c <= '9' || (c == '-' && i+1 < len(s) &&
+		    s[i+1] >= '0' && s[i+1] <= '9'):
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' ||
+			    s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{"number", s[i:j]})
+			i = j
+		case strings.IndexByte("=<>!(),", c) >= 0:
+			op := string(c)
+			if i+1 < len(s) {
+				switch s[i:i+2] {
+				case "<=", ">=", "<>", "!=":
+					op = s[i:i+2]
+				}
+			}
+			tokens = append(tokens, token{"op", op})
+			i += len(op)
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] == '.' ||
+			    unicode.IsLetter(rune(s[j])) ||
+			    unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			word := s[i:j]
+			if filterWords[strings.ToUpper(word)] == true {
+				tokens = append(tokens, token{"word",
+				    strings.ToUpper(word)})
+			} else {
+				tokens = append(tokens, token{"ident", word})
+			}
+			i = j
+		default:
+			return nil, fmt.Errorf("matilda memory: " +
+			    "Unexpected %q in filter.", c)
+		}
+	}
+	return append(tokens, token{"end", ""}), nil
+}
+
+// Compile a filter into a row predicate
+func parseFilter(table *matilda.Table, filter string,
+    params []interface{}) (predicate, error) {
+
+	if strings.TrimSpace(filter) == "" {
+		return func(map[string]interface{}) bool { return true }, nil
+	}
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{table: table, params: params, tokens: tokens}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != "end" {
+		return nil, p.unexpected()
+	}
+	return pred, nil
+}
+
+func (p *filterParser) peek() token {
+
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+
+	t := p.tokens[p.pos]
+	if t.kind != "end" {
+		p.pos++
+	}
+	return t
+}
+
+// Consume the next token when it matches
+func (p *filterParser) accept(kind, text string) bool {
+
+	if t := p.peek(); t.kind == kind && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) unexpected() error {
+
+	t := p.peek()
+	if t.kind == "end" {
+		return fmt.Errorf("matilda memory: Unexpected end of filter.")
+	}
+	return fmt.Errorf("matilda memory: Unexpected %q in filter.", t.text)
+}
+
+func (p *filterParser) or() (predicate, error) {
+
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("word", "OR") == true {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(row map[string]interface{}) bool {
+			return l(row) || r(row)
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (predicate, error) {
+
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("word", "AND") == true {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(row map[string]interface{}) bool {
+			return l(row) && r(row)
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) not() (predicate, error) {
+
+	if p.accept("word", "NOT") == true {
+		pred, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(row map[string]interface{}) bool {
+			return !pred(row)
+		}, nil
+	}
+	return p.primary()
+}
+
+func (p *filterParser) primary() (predicate, error) {
+
+	if p.accept("op", "(") == true {
+		pred, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept("op", ")") == false {
+			return nil, p.unexpected()
+		}
+		return pred, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	// IS [NOT] NULL
+	if p.accept("word", "IS") == true {
+		not := p.accept("word", "NOT")
+		if p.accept("word", "NULL") == false {
+			return nil, p.unexpected()
+		}
+		return func(row map[string]interface{}) bool {
+			return (left(row) == nil) != not
+		}, nil
+	}
+
+	not := p.accept("word", "NOT")
+	switch {
+	case p.accept("word", "LIKE") == true:
+		return p.like(left, not)
+	case p.accept("word", "IN") == true:
+		return p.in(left, not)
+	case not == true:
+		return nil, p.unexpected()
+	}
+
+	t := p.next()
+	if t.kind != "op" {
+		return nil, p.unexpected()
+	}
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	var test func(int) bool
+	switch t.text {
+	case "=":
+		test = func(c int) bool { return c == 0 }
+	case "<>", "!=":
+		test = func(c int) bool { return c != 0 }
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	case ">=":
+		test = func(c int) bool { return c >= 0 }
+	default:
+		return nil, fmt.Errorf("matilda memory: Unexpected %q in " +
+		    "filter.", t.text)
+	}
+	return func(row map[string]interface{}) bool {
+		c, ok := compare(left(row), right(row))
+		return ok && test(c)
+	}, nil
+}
+
+func (p *filterParser) like(left operand, not bool) (predicate, error) {
+
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	return func(row map[string]interface{}) bool {
+		l, lok := normalize(left(row)).(string)
+		r, rok := normalize(right(row)).(string)
+		if lok == false || rok == false {
+			return false
+		}
+		return likeMatch(l, r) != not
+	}, nil
+}
+
+func (p *filterParser) in(left operand, not bool) (predicate, error) {
+	var list []operand
+
+	if p.accept("op", "(") == false {
+		return nil, p.unexpected()
+	}
+	for {
+		op, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, op)
+		if p.accept("op", ",") == false {
+			break
+		}
+	}
+	if p.accept("op", ")") == false {
+		return nil, p.unexpected()
+	}
+	return func(row map[string]interface{}) bool {
+		val := left(row)
+		for _, op := range list {
+			if c, ok := compare(val, op(row)); ok && c == 0 {
+				return !not
+			}
+		}
+		return not
+	}, nil
+}
+
+func constant(val interface{}) operand {
+
+	return func(map[string]interface{}) interface{} { return val }
+}
+
+func (p *filterParser) operand() (operand, error) {
+
+	t := p.next()
+	switch t.kind {
+	case "ident":
+		name := t.text
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[i+1:]
+		}
+		if p.table.GetColumn(name) == nil {
+			return nil, fmt.Errorf("matilda memory: Column " +
+			    "%q not found.", name)
+		}
+		return func(row map[string]interface{}) interface{} {
+			return row[name]
+		}, nil
+	case "string":
+		return constant(t.text), nil
+	case "number":
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return constant(n), nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("matilda memory: Bad " +
+			    "number %q in filter.", t.text)
+		}
+		return constant(f), nil
+	case "param":
+		var n int
+		if t.text == "" {
+			p.nextParam++
+			n = p.nextParam
+		} else {
+			n, _ = strconv.Atoi(t.text)
+		}
+		if n < 1 || n > len(p.params) {
+			return nil, fmt.Errorf("matilda memory: Missing " +
+			    "parameter %d.", n)
+		}
+		return constant(p.params[n-1]), nil
+	case "word":
+		switch t.text {
+		case "NULL":
+			return constant(nil), nil
+		case "TRUE":
+			return constant(true), nil
+		case "FALSE":
+			return constant(false), nil
+		}
+	}
+	p.pos--
+	return nil, p.unexpected()
+}
+
+// Compare values, ok is false when they are not comparable
+func compare(a, b interface{}) (c int, ok bool) {
+
+	a, b = normalize(a), normalize(b)
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return cmpInt(x, y), true
+		case float64:
+			return cmpFloat(float64(x), y), true
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return cmpFloat(x, float64(y)), true
+		case float64:
+			return cmpFloat(x, y), true
+		}
+	case string:
+		if y, ok := b.(string); ok == true {
+			return strings.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok == true {
+			switch {
+			case x == y:
+				return 0, true
+			case x == false:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok == true {
+			switch {
+			case x.Before(y):
+				return -1, true
+			case x.After(y):
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func cmpInt(x, y int64) int {
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func cmpFloat(x, y float64) int {
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func likeMatch(s, pattern string) bool {
+	var b strings.Builder
+
+	b.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(s)
+}
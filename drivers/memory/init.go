@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"database/sql"
+
+	"github.com/radixo/matilda"
+)
+
+func init() {
+
+	sql.Register(DriverName, &Driver{})
+
+	// Drivers registration
+	matilda.RegisterCRUDDriver("*memory.Driver", NewCRUDDriver)
+	matilda.RegisterCRUDDriver(DriverName, NewCRUDDriver)
+}
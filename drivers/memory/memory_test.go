@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+func openTable(t *testing.T) (*sql.DB, *matilda.Table) {
+
+	Reset(t.Name())
+	db, err := sql.Open(DriverName, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		Reset(t.Name())
+	})
+	tb := matilda.NewTable(nil, db, "people",
+	    matilda.NewColAutoIncPK("id"),
+	    matilda.NewCol("name", &matilda.VdrString{}),
+	    matilda.NewCol("age", &matilda.VdrInt64{}))
+	err = tb.Insert(map[string]interface{}{"name": "ann", "age": 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, tb
+}
+
+func TestRollbackKeepsOtherWrites(t *testing.T) {
+	var ctx = context.Background()
+
+	db, tb := openTable(t)
+	other, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tb.UpdateCtx(ctx, tx, map[string]interface{}{"id": 1,
+	    "name": "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tb.UpdateCtx(ctx, other, map[string]interface{}{"id": 1,
+	    "age": 31})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tb.InsertCtx(ctx, other, map[string]interface{}{"id": 2,
+	    "name": "cy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tb.DeleteWhereCtx(ctx, tx, "id = ?", 2); err != nil {
+		t.Fatal(err)
+	}
+	err = tb.InsertCtx(ctx, other, map[string]interface{}{"id": 2,
+	    "name": "dee"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := tb.SelectByKey(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row["name"] != "ann" || row["age"] != int64(31) {
+		t.Fatalf("got %v, want name rolled back and age kept", row)
+	}
+	if row, _ = tb.SelectByKey(nil, 2); row["name"] != "dee" {
+		t.Fatalf("got %v, want the row inserted after the delete", row)
+	}
+}
+
+func TestSavepoints(t *testing.T) {
+	var ctx = context.Background()
+
+	db, tb := openTable(t)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	set, rollback, release := NewCRUDDriver(tb).(
+	    matilda.SavepointDriver).Savepoint("sp")
+	exec := func(stmt string) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	update := func(age int) {
+		err := tb.UpdateCtx(ctx, tx, map[string]interface{}{"id": 1,
+		    "age": age})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	age := func() interface{} {
+		row, err := tb.SelectByKeyTx(tx, nil, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return row["age"]
+	}
+
+	exec(set)
+	update(40)
+	exec(set)
+	update(50)
+	exec(rollback)
+	if got := age(); got != int64(40) {
+		t.Fatalf("got age %v, want 40", got)
+	}
+	exec(release)
+	exec(rollback)
+	if got := age(); got != int64(30) {
+		t.Fatalf("got age %v, want 30", got)
+	}
+	exec(release)
+	if _, err = tx.ExecContext(ctx, rollback); err == nil {
+		t.Fatal("rolled back to a released savepoint")
+	}
+	if _, err = db.ExecContext(ctx, set); err == nil {
+		t.Fatal("savepoint set out of a transaction")
+	}
+}
+
+func TestBeginTxOptions(t *testing.T) {
+	var ctx = context.Background()
+
+	db, tb := openTable(t)
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{
+	    Isolation: sql.LevelSerializable, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err = tb.SelectByKeyTx(tx, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	err = tb.UpdateCtx(ctx, tx, map[string]interface{}{"id": 1, "age": 1})
+	if err == nil {
+		t.Fatal("read-only transaction wrote")
+	}
+}
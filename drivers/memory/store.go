@@ -0,0 +1,452 @@
+package memory
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/radixo/matilda"
+)
+
+// Operation arguments sent by the CRUD driver
+type command struct {
+	table *matilda.Table
+	data map[string]interface{}
+	cols []string
+	keys []interface{}
+	filter string
+	params []interface{}
+	upsert *matilda.UpsertOpts
+	rows []map[string]interface{}
+}
+
+type memTable struct {
+	// Rows by key
+	rows map[string]map[string]interface{}
+
+	// Keys in insertion order
+	order []string
+
+	// Last auto incremental value
+	autoInc int64
+
+	// Row counter, used as key on tables without primary keys
+	seq int64
+}
+
+type store struct {
+	sync.Mutex
+
+	tables map[string]*memTable
+}
+
+func newStore() *store {
+
+	return &store{tables: make(map[string]*memTable)}
+}
+
+func (s *store) table(name string) *memTable {
+
+	t, ok := s.tables[name]
+	if ok == false {
+		t = &memTable{rows: make(map[string]map[string]interface{})}
+		s.tables[name] = t
+	}
+	return t
+}
+
+// Record how to undo a write done inside a transaction
+func record(tx *tx, f func()) {
+
+	if tx != nil {
+		tx.undo = append(tx.undo, f)
+	}
+}
+
+// Normalize values so equal keys and comparisons match across Go types
+func normalize(val interface{}) interface{} {
+
+	switch v := val.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	case matilda.UID:
+		return v.String()
+	default:
+		return val
+	}
+}
+
+// Report if a and b are equal as keys
+func same(a, b interface{}) bool {
+
+	return rowKey([]interface{}{a}) == rowKey([]interface{}{b})
+}
+
+func rowKey(vals []interface{}) string {
+	var parts []string
+
+	for _, val := range vals {
+		val = normalize(val)
+		parts = append(parts, fmt.Sprintf("%T:%v", val, val))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func pkeyKey(table *matilda.Table, data map[string]interface{}) (
+    string, error) {
+	var vals []interface{}
+
+	for _, col := range table.PKeys {
+		val, ok := data[col.Name]
+		if ok == false || val == nil {
+			return "", fmt.Errorf("matilda memory: Key %q not " +
+			    "present in data.", col.Name)
+		}
+		vals = append(vals, val)
+	}
+	return rowKey(vals), nil
+}
+
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	var ret = make(map[string]interface{}, len(row))
+
+	for k, v := range row {
+		ret[k] = v
+	}
+	return ret
+}
+
+func (t *memTable) remove(key string) (pos int) {
+
+	for i, k := range t.order {
+		if k == key {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			pos = i
+			break
+		}
+	}
+	delete(t.rows, key)
+	return pos
+}
+
+// Put back a removed row, unless its key was taken meanwhile
+func (t *memTable) restore(key string, row map[string]interface{},
+    pos int) {
+
+	if _, ok := t.rows[key]; ok == true {
+		return
+	}
+	if pos > len(t.order) {
+		pos = len(t.order)
+	}
+	t.rows[key] = row
+	t.order = append(t.order, "")
+	copy(t.order[pos+1:], t.order[pos:])
+	t.order[pos] = key
+}
+
+// Set vals on the row at key, recording how to undo each column; columns
+// written again meanwhile by other connections are kept
+func (t *memTable) set(tx *tx, key string, vals map[string]interface{}) {
+	var old = make(map[string]interface{}, len(vals))
+
+	row := t.rows[key]
+	for col, val := range vals {
+		old[col] = row[col]
+		row[col] = val
+	}
+	record(tx, func() {
+		cur, ok := t.rows[key]
+		if ok == false {
+			return
+		}
+		for col, val := range vals {
+			if same(cur[col], val) == true {
+				cur[col] = old[col]
+			}
+		}
+	})
+}
+
+// Values of data for the table columns
+func columnValues(table *matilda.Table,
+    data map[string]interface{}) map[string]interface{} {
+	var vals = make(map[string]interface{})
+
+	for _, col := range table.Columns {
+		if val, ok := data[col.Name]; ok == true {
+			vals[col.Name] = val
+		}
+	}
+	return vals
+}
+
+func (s *store) exec(tx *tx, op string, cmd *command) (driver.Result,
+    error) {
+
+	s.Lock()
+	defer s.Unlock()
+	switch op {
+	case "insert":
+		return s.insert(tx, cmd)
+	case "update":
+		return s.update(tx, cmd)
+	case "delete":
+		return s.delete(tx, cmd)
+	case "upsert":
+		return s.upsert(tx, cmd)
+	case "insert_many":
+		return s.insertMany(tx, cmd)
+	case "update_where":
+		return s.updateWhere(tx, cmd)
+	case "delete_where":
+		return s.deleteWhere(tx, cmd)
+	default:
+		return nil, fmt.Errorf("matilda memory: Unknown operation %q.",
+		    op)
+	}
+}
+
+func (s *store) insert(tx *tx, cmd *command) (driver.Result, error) {
+	var res = &result{rowsAffected: 1}
+	var key string
+	var err error
+
+	t := s.table(cmd.table.Name)
+	row := make(map[string]interface{})
+	for _, col := range cmd.table.AllColumns {
+		if val, ok := cmd.data[col.Name]; ok == true {
+			row[col.Name] = val
+		}
+	}
+
+	for _, col := range cmd.table.AllColumns {
+		if col.AutoInc == false {
+			continue
+		}
+		switch v := normalize(row[col.Name]).(type) {
+		case nil:
+			t.autoInc++
+			row[col.Name] = t.autoInc
+		case int64:
+			if v > t.autoInc {
+				t.autoInc = v
+			}
+		}
+		if v, ok := normalize(row[col.Name]).(int64); ok == true {
+			res.lastInsertId = v
+			res.hasInsertId = true
+		}
+		break
+	}
+
+	if len(cmd.table.PKeys) == 0 {
+		t.seq++
+		key = fmt.Sprint(t.seq)
+	} else if key, err = pkeyKey(cmd.table, row); err != nil {
+		return nil, err
+	}
+	if _, ok := t.rows[key]; ok == true {
+		return nil, fmt.Errorf("matilda memory: Duplicate key on " +
+		    "table %q.", cmd.table.Name)
+	}
+
+	t.rows[key] = row
+	t.order = append(t.order, key)
+	record(tx, func() {
+		t.remove(key)
+	})
+	return res, nil
+}
+
+// Insert all rows or none, like a single statement
+func (s *store) insertMany(cur *tx, cmd *command) (driver.Result, error) {
+	var batch = new(tx)
+
+	for _, data := range cmd.rows {
+		_, err := s.insert(batch, &command{table: cmd.table,
+		    data: data})
+		if err != nil {
+			for i := len(batch.undo) - 1; i >= 0; i-- {
+				batch.undo[i]()
+			}
+			return nil, err
+		}
+	}
+	if cur != nil {
+		cur.undo = append(cur.undo, batch.undo...)
+	}
+	return &result{rowsAffected: int64(len(cmd.rows))}, nil
+}
+
+func (s *store) update(tx *tx, cmd *command) (driver.Result, error) {
+
+	t := s.table(cmd.table.Name)
+	key, err := pkeyKey(cmd.table, cmd.data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := t.rows[key]; ok == false {
+		return &result{}, nil
+	}
+
+	t.set(tx, key, columnValues(cmd.table, cmd.data))
+	return &result{rowsAffected: 1}, nil
+}
+
+func (s *store) upsert(tx *tx, cmd *command) (driver.Result, error) {
+	var key string
+
+	t := s.table(cmd.table.Name)
+	_loop:
+	for _, k := range t.order {
+		for _, col := range cmd.upsert.Conflict {
+			if rowKey([]interface{}{t.rows[k][col]}) !=
+			    rowKey([]interface{}{cmd.data[col]}) {
+				continue _loop
+			}
+		}
+		key = k
+		break
+	}
+	if key == "" {
+		return s.insert(tx, cmd)
+	}
+	if len(cmd.upsert.Update) == 0 {
+		return &result{}, nil
+	}
+
+	vals := make(map[string]interface{}, len(cmd.upsert.Update))
+	for _, col := range cmd.upsert.Update {
+		val, ok := cmd.upsert.Values[col]
+		if ok == false {
+			val = cmd.data[col]
+		}
+		vals[col] = val
+	}
+	t.set(tx, key, vals)
+	return &result{rowsAffected: 1}, nil
+}
+
+func (s *store) delete(tx *tx, cmd *command) (driver.Result, error) {
+
+	t := s.table(cmd.table.Name)
+	key, err := pkeyKey(cmd.table, cmd.data)
+	if err != nil {
+		return nil, err
+	}
+	row, ok := t.rows[key]
+	if ok == false {
+		return &result{}, nil
+	}
+
+	pos := t.remove(key)
+	record(tx, func() {
+		t.restore(key, row, pos)
+	})
+	return &result{rowsAffected: 1}, nil
+}
+
+func (s *store) updateWhere(tx *tx, cmd *command) (driver.Result, error) {
+	var res = &result{}
+
+	match, err := parseFilter(cmd.table, cmd.filter, cmd.params)
+	if err != nil {
+		return nil, err
+	}
+	t := s.table(cmd.table.Name)
+	vals := columnValues(cmd.table, cmd.data)
+	for _, key := range t.order {
+		if match(t.rows[key]) == false {
+			continue
+		}
+		t.set(tx, key, vals)
+		res.rowsAffected++
+	}
+	return res, nil
+}
+
+func (s *store) deleteWhere(tx *tx, cmd *command) (driver.Result, error) {
+	var res = &result{}
+	var keys []string
+
+	match, err := parseFilter(cmd.table, cmd.filter, cmd.params)
+	if err != nil {
+		return nil, err
+	}
+	t := s.table(cmd.table.Name)
+	for _, key := range t.order {
+		if match(t.rows[key]) == true {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		row, k := t.rows[key], key
+		pos := t.remove(key)
+		record(tx, func() {
+			t.restore(k, row, pos)
+		})
+		res.rowsAffected++
+	}
+	return res, nil
+}
+
+func (s *store) query(op string, cmd *command) (driver.Rows, error) {
+	var ret = &rows{cols: cmd.cols}
+
+	s.Lock()
+	defer s.Unlock()
+	for _, name := range cmd.cols {
+		if cmd.table.GetColumn(name) == nil {
+			return nil, fmt.Errorf("matilda memory: Column " +
+			    "%q not found.", name)
+		}
+	}
+
+	t := s.table(cmd.table.Name)
+	switch op {
+	case "select_key":
+		if len(cmd.keys) != len(cmd.table.PKeys) {
+			return nil, fmt.Errorf("matilda memory: Table %q has " +
+			    "%d keys.", cmd.table.Name, len(cmd.table.PKeys))
+		}
+		if row, ok := t.rows[rowKey(cmd.keys)]; ok == true {
+			ret.data = append(ret.data, copyRow(row))
+		}
+	case "select":
+		match, err := parseFilter(cmd.table, cmd.filter, cmd.params)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range t.order {
+			if row := t.rows[key]; match(row) == true {
+				ret.data = append(ret.data, copyRow(row))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("matilda memory: Unknown operation %q.",
+		    op)
+	}
+	return ret, nil
+}
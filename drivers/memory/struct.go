@@ -0,0 +1,14 @@
+package memory
+
+import (
+
+	"github.com/radixo/matilda"
+)
+
+type MemCRUDDriver struct {
+	// Entity type
+	etype matilda.EntityType
+
+	// Pointer to table being used by the driver
+	table *matilda.Table
+}
@@ -0,0 +1,128 @@
+package mssql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// SQL Server SQL dialect
+type MssqlDialect struct {
+	drivers.BaseDialect
+}
+
+func (d MssqlDialect) Quote(s string) string {
+	var n = len(s)
+
+	if n > 2 && s[0] == '[' && s[n-1] == ']' {
+		return s
+	}
+	return "[" + strings.Replace(s, "]", "]]", -1) + "]"
+}
+
+func (d MssqlDialect) Placeholder(n int) string {
+
+	return fmt.Sprintf("@p%d", n)
+}
+
+// Columns holding UIDs as uniqueidentifier
+func uidColumn(col *matilda.Column) bool {
+
+	if col.Typ != "" && strings.EqualFold(col.Typ, "uniqueidentifier") ==
+	    false {
+		return false
+	}
+	for _, vdr := range col.Validators {
+		if _, ok := vdr.(*matilda.VdrUID); ok == true {
+			return true
+		}
+	}
+	return false
+}
+
+// UIDs go as dashed strings, the only text uniqueidentifier accepts
+func (d MssqlDialect) Value(val interface{}) interface{} {
+
+	if uid, ok := val.(matilda.UID); ok == true {
+		if s := uid.String(); len(s) == 32 {
+			return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" +
+			    s[16:20] + "-" + s[20:]
+		}
+	}
+	return d.BaseDialect.Value(val)
+}
+
+// Scanned uniqueidentifiers have their first three groups little endian
+func (d MssqlDialect) ScanValue(col *matilda.Column,
+    val interface{}) interface{} {
+
+	b, ok := val.([]byte)
+	if ok == false || len(b) != 16 || uidColumn(col) == false {
+		return val
+	}
+	return []byte{b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6], b[8],
+	    b[9], b[10], b[11], b[12], b[13], b[14], b[15]}
+}
+
+// Upserts need MERGE, which isn't a clause of INSERT
+func (d MssqlDialect) Capabilities() matilda.Capability {
+
+	return matilda.CAP_RETURNING | matilda.CAP_SAVEPOINT |
+	    matilda.CAP_ROW_LOCKING
+}
+
+// Savepoints are released by the transaction end only
+func (d MssqlDialect) Savepoint(name string) (set, rollback,
+    release string) {
+
+	return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, ""
+}
+
+// Requests take 2100 parameters, sp_executesql uses two of them
+func (d MssqlDialect) MaxParams() int {
+
+	return 2098
+}
+
+// Table value constructors take 1000 rows
+func (d MssqlDialect) MaxRows() int {
+
+	return 1000
+}
+
+// Returned columns come from OUTPUT, placed before the values
+func (d MssqlDialect) Insert(table string, cols, params []string,
+    conflict string, ret []string) string {
+	var output []string
+
+	sql := "INSERT INTO " + table
+	if len(cols) > 0 {
+		sql += "(" + strings.Join(cols, ",") + ")"
+	}
+	for _, col := range ret {
+		output = append(output, "INSERTED." + col)
+	}
+	if len(output) > 0 {
+		sql += " OUTPUT " + strings.Join(output, ",")
+	}
+	if len(cols) == 0 {
+		return sql + " DEFAULT VALUES;"
+	}
+	return sql + " VALUES(" + strings.Join(params, ",") + ");"
+}
+
+func (d MssqlDialect) Select(table string, cols []string, filter string,
+    limit int) string {
+
+	sql := "SELECT "
+	if limit > 0 {
+		sql += fmt.Sprintf("TOP %d ", limit)
+	}
+	sql += strings.Join(cols, ",") + " FROM " + table
+	if filter != "" {
+		sql += " WHERE " + filter
+	}
+	return sql + ";"
+}
@@ -0,0 +1,39 @@
+package mssql
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+func TestUIDRoundTrip(t *testing.T) {
+	var uid matilda.UID
+
+	d := MssqlDialect{}
+	err := uid.ReadString("00112233445566778899aabbccddeeff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "00112233-4455-6677-8899-aabbccddeeff"
+	if got := d.Value(uid); got != want {
+		t.Fatalf("got %v, want %s", got, want)
+	}
+
+	// Bytes as SQL Server returns them for want
+	wire := []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88,
+	    0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	col := matilda.NewCol("u", &matilda.VdrUID{})
+	got, _ := d.ScanValue(col, wire).([]byte)
+	v, _ := uid.Value()
+	if bytes.Equal(got, v.([]byte)) == false {
+		t.Fatalf("got %x, want %x", got, v)
+	}
+
+	// Other columns keep their bytes
+	col = matilda.NewCol("b", &matilda.VdrString{})
+	got, _ = d.ScanValue(col, wire).([]byte)
+	if bytes.Equal(got, wire) == false {
+		t.Fatalf("got %x, want %x", got, wire)
+	}
+}
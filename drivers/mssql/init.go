@@ -0,0 +1,27 @@
+package mssql
+
+import (
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// sql.Register names for explicit selection, as with
+//
+//	matilda.SetDBDriver(db, "sqlserver")
+//
+// Only the "sqlserver" name takes @pN placeholders. Databases opened as
+// "mssql" share its *mssql.Driver type, so the type is not registered and
+// those databases find no driver.
+var knownDrivers = []string {
+	"sqlserver",
+}
+
+func init() {
+
+	// Drivers registration
+	for _, dname := range knownDrivers {
+		matilda.RegisterCRUDDriver(dname,
+		    drivers.NewSqlCRUDDriver(MssqlDialect{}))
+	}
+}
@@ -0,0 +1,42 @@
+package mssql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+// Driver named as the go-mssqldb one, shared by "mssql" and "sqlserver"
+type Driver struct {
+}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+
+	return nil, errors.New("no server")
+}
+
+func init() {
+
+	sql.Register("matilda-test-mssql", &Driver{})
+}
+
+func TestBindByName(t *testing.T) {
+
+	db, err := sql.Open("matilda-test-mssql", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err = matilda.OpenTable(nil, db, "items"); err == nil {
+		t.Fatal("bound by driver type")
+	}
+	matilda.SetDBDriver(db, "sqlserver")
+	defer matilda.SetDBDriver(db, "")
+	if _, err = matilda.OpenTable(nil, db, "items"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// MySQL and MariaDB SQL dialect
+type MysqlDialect struct {
+	drivers.BaseDialect
+}
+
+func (d MysqlDialect) Quote(s string) string {
+	var n = len(s)
+
+	if n > 2 && s[0] == '`' && s[n-1] == '`' {
+		return s
+	}
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+func (d MysqlDialect) Capabilities() matilda.Capability {
+
+	return matilda.CAP_UPSERT | matilda.CAP_SAVEPOINT |
+	    matilda.CAP_ROW_LOCKING
+}
+
+func (d MysqlDialect) Insert(table string, cols, params []string,
+    conflict string, ret []string) string {
+
+	if len(cols) == 0 {
+		if conflict != "" {
+			return fmt.Sprintf("INSERT INTO %s()VALUES() %s;",
+			    table, conflict)
+		}
+		return fmt.Sprintf("INSERT INTO %s()VALUES();", table)
+	}
+	return d.BaseDialect.Insert(table, cols, params, conflict, nil)
+}
+
+// Prepared statements count parameters in 16 bits
+func (d MysqlDialect) MaxParams() int {
+
+	return 65535
+}
+
+// ON DUPLICATE KEY fires on any primary or unique key, so only primary key
+// conflicts are supported
+func (d MysqlDialect) OnConflict(conflict, update, params []string,
+    pkey bool) string {
+	var set []string
+
+	if pkey == false {
+		return ""
+	}
+	if len(update) == 0 {
+		// Assign a key to itself to ignore the row
+		return "ON DUPLICATE KEY UPDATE " + conflict[0] + " = " +
+		    conflict[0]
+	}
+	for i, col := range update {
+		set = append(set, col + " = " + params[i])
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ",")
+}
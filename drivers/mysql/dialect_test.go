@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+func TestUpsertConflict(t *testing.T) {
+
+	tb := matilda.NewTable(nil, nil, "users",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("email", &matilda.VdrString{}))
+	d := drivers.NewSqlCRUDDriver(MysqlDialect{})(tb).(
+	    *drivers.SqlCRUDDriver)
+
+	// Refused before reaching the database
+	err := d.Upsert(nil, nil, map[string]interface{}{"email": "a"},
+	    &matilda.UpsertOpts{Conflict: []string{"email"}})
+	if errors.Is(err, matilda.ErrUnsupported) == false {
+		t.Fatalf("got %v, want ErrUnsupported", err)
+	}
+
+	got := MysqlDialect{}.OnConflict([]string{"`id`"},
+	    []string{"`email`"}, []string{"?"}, true)
+	want := "ON DUPLICATE KEY UPDATE `email` = ?"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
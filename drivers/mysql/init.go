@@ -0,0 +1,22 @@
+package mysql
+
+import (
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// Driver types, and sql.Register names for explicit selection
+var knownDrivers = []string {
+	"*mysql.MySQLDriver",
+	"mysql",
+}
+
+func init() {
+
+	// Drivers registration
+	for _, dname := range knownDrivers {
+		matilda.RegisterCRUDDriver(dname,
+		    drivers.NewSqlCRUDDriver(MysqlDialect{}))
+	}
+}
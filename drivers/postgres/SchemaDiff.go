@@ -0,0 +1,514 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/radixo/matilda"
+)
+
+// Column as found on database
+type pgColumn struct {
+	name string
+	typ string
+	notNull bool
+	def string
+	identity bool
+}
+
+const tableExistsSQL = `SELECT EXISTS (SELECT 1
+    FROM information_schema.tables
+    WHERE table_schema = current_schema() AND table_name = $1);`
+
+const columnsSQL = `SELECT a.attname, format_type(a.atttypid, a.atttypmod),
+    a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), ''),
+    a.attidentity <> ''
+    FROM pg_attribute a
+    JOIN pg_class c ON c.oid = a.attrelid
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+    WHERE n.nspname = current_schema() AND c.relname = $1 AND a.attnum > 0
+    AND NOT a.attisdropped
+    ORDER BY a.attnum;`
+
+// Index as found on database
+type pgIndex struct {
+	name string
+	unique bool
+
+	// Key columns, with DESC when descending
+	cols string
+
+	// Partial index predicate
+	where string
+}
+
+// Indexes not backing a constraint, such as the primary key
+const indexesSQL = `SELECT i.relname, x.indisunique,
+    array_to_string(ARRAY(SELECT
+    pg_get_indexdef(x.indexrelid, k + 1, true) ||
+    CASE WHEN x.indoption[k] & 1 = 1 THEN ' DESC' ELSE '' END
+    FROM generate_series(0, x.indnkeyatts - 1) k ORDER BY k), ','),
+    COALESCE(pg_get_expr(x.indpred, x.indrelid, true), '')
+    FROM pg_index x
+    JOIN pg_class i ON i.oid = x.indexrelid
+    JOIN pg_class c ON c.oid = x.indrelid
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE n.nspname = current_schema() AND c.relname = $1
+    AND NOT EXISTS (SELECT 1 FROM pg_constraint k
+    WHERE k.conindid = x.indexrelid)
+    ORDER BY i.relname;`
+
+// CHECK and FOREIGN KEY constraints
+const constraintsSQL = `SELECT k.conname, pg_get_constraintdef(k.oid, true)
+    FROM pg_constraint k
+    JOIN pg_class c ON c.oid = k.conrelid
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE n.nspname = current_schema() AND c.relname = $1
+    AND k.contype IN ('c', 'f')
+    ORDER BY k.conname;`
+
+// Type aliases as shown by format_type
+var typAliases = map[string]string{
+	"int": "integer",
+	"int4": "integer",
+	"serial": "integer",
+	"serial4": "integer",
+	"int2": "smallint",
+	"smallserial": "smallint",
+	"serial2": "smallint",
+	"int8": "bigint",
+	"bigserial": "bigint",
+	"serial8": "bigint",
+	"bool": "boolean",
+	"float4": "real",
+	"float8": "double precision",
+	"float": "double precision",
+	"varchar": "character varying",
+	"char": "character",
+	"bpchar": "character",
+	"decimal": "numeric",
+	"timestamp": "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time": "time without time zone",
+	"timetz": "time with time zone",
+}
+
+var spaces = regexp.MustCompile(`\s+`)
+
+// Normalize a type name to the format_type output
+func normalizeTyp(typ string) string {
+	var mod string
+
+	typ = strings.ToLower(spaces.ReplaceAllString(strings.TrimSpace(typ),
+	    " "))
+	if i := strings.Index(typ, "("); i >= 0 {
+		mod = strings.Replace(typ[i:], " ", "", -1)
+		typ = strings.TrimSpace(typ[:i])
+	}
+	if alias, ok := typAliases[typ]; ok == true {
+		typ = alias
+	}
+	return typ + mod
+}
+
+var defaultCast = regexp.MustCompile(`::[a-z ]+(\([0-9, ]*\))?(\[\])?$`)
+
+// Normalize a default expression for comparison
+func normalizeDefault(def string) string {
+
+	def = strings.TrimSpace(def)
+	for defaultCast.MatchString(def) {
+		def = strings.TrimSpace(defaultCast.ReplaceAllString(def, ""))
+	}
+	if n := len(def); n >= 2 && def[0] == '\'' && def[n-1] == '\'' {
+		return strings.Replace(def[1:n-1], "''", "'", -1)
+	}
+	if def == "true" || def == "false" {
+		return strings.ToUpper(def)
+	}
+	return def
+}
+
+var exprCast = regexp.MustCompile(`::(character varying|double precision|` +
+    `time(stamp)? with(out)? time zone|[a-z_0-9]+)(\([0-9, ]*\))?(\[\])?`)
+
+var exprBetween = regexp.MustCompile(`(\S+) between (\S+) and (\S+)`)
+
+// Normalize an expression for comparison with its database version, which
+// gets parentheses and casts added
+func normalizeExpr(expr string) string {
+
+	expr = strings.ToLower(spaces.ReplaceAllString(
+	    strings.TrimSpace(expr), " "))
+	expr = exprBetween.ReplaceAllString(expr, "$1 >= $2 and $1 <= $3")
+	expr = exprCast.ReplaceAllString(expr, "")
+	return strings.NewReplacer("(", "", ")", "", `"`, "", " ",
+	    "").Replace(expr)
+}
+
+func (p *PgSchemaDriver) query(ex matilda.Executor, sql string,
+    params ...interface{}) (*sql.Rows, error) {
+
+	return ex.QueryContext(context.Background(), sql, params...)
+}
+
+func (p *PgSchemaDriver) tableExists(ex matilda.Executor) (exists bool,
+    err error) {
+
+	row := ex.QueryRowContext(context.Background(), tableExistsSQL,
+	    p.table.Name)
+	err = row.Scan(&exists)
+	return
+}
+
+func (p *PgSchemaDriver) dbColumns(ex matilda.Executor) (
+    cols []*pgColumn, err error) {
+
+	rows, err := p.query(ex, columnsSQL, p.table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		col := new(pgColumn)
+		if err = rows.Scan(&col.name, &col.typ, &col.notNull, &col.def,
+		    &col.identity); err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
+}
+
+func (p *PgSchemaDriver) dbIndexes(ex matilda.Executor) (
+    idxs []*pgIndex, err error) {
+
+	rows, err := p.query(ex, indexesSQL, p.table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		idx := new(pgIndex)
+		if err = rows.Scan(&idx.name, &idx.unique, &idx.cols,
+		    &idx.where); err != nil {
+			return nil, err
+		}
+		idxs = append(idxs, idx)
+	}
+	return idxs, rows.Err()
+}
+
+// Constraint definitions by name
+func (p *PgSchemaDriver) dbConstraints(ex matilda.Executor) (
+    cons map[string]string, err error) {
+
+	rows, err := p.query(ex, constraintsSQL, p.table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cons = make(map[string]string)
+	for rows.Next() {
+		var name, def string
+		if err = rows.Scan(&name, &def); err != nil {
+			return nil, err
+		}
+		cons[name] = def
+	}
+	return cons, rows.Err()
+}
+
+// Report if the database index has other columns, uniqueness or predicate
+func indexChanged(idx *matilda.Index, dbidx *pgIndex) bool {
+	var cols []string
+
+	for i, col := range idx.GetColumns() {
+		if idx.IsAsc(i) == true {
+			cols = append(cols, assureIdentifier(col))
+		} else {
+			cols = append(cols, assureIdentifier(col) + " DESC")
+		}
+	}
+	return (idx.GetType() == matilda.UNIQ) != dbidx.unique ||
+	    normalizeExpr(strings.Join(cols, ",")) !=
+	    normalizeExpr(dbidx.cols) ||
+	    normalizeExpr(idx.GetWhere()) != normalizeExpr(dbidx.where)
+}
+
+func (p *PgSchemaDriver) diffColumn(col *matilda.Column,
+    dbcol *pgColumn) (*matilda.ColumnDiff, error) {
+	var cd = new(matilda.ColumnDiff)
+
+	cd.Column = col
+	cd.DBTyp = dbcol.typ
+	cd.DBNotNull = dbcol.notNull
+	cd.DBDefault = dbcol.def
+
+	typ, err := p.columnTyp(col)
+	if err != nil {
+		return nil, err
+	}
+	cd.TypChanged = normalizeTyp(typ) != normalizeTyp(dbcol.typ)
+
+	notNull := col.IsNotNull() || col.PKey || col.AutoInc
+	cd.NotNullChanged = notNull != dbcol.notNull
+
+	// Auto incremental columns have their own defaults
+	if col.AutoInc == false {
+		var def string
+		if val := col.DefaultValue(); val != nil {
+			lit, err := sqlLiteral(val)
+			if err != nil {
+				return nil, err
+			}
+			def = normalizeDefault(lit)
+		}
+		cd.DefaultChanged = def != normalizeDefault(dbcol.def)
+	}
+
+	if cd.TypChanged || cd.NotNullChanged || cd.DefaultChanged {
+		return cd, nil
+	}
+	return nil, nil
+}
+
+func (p *PgSchemaDriver) Diff(ex matilda.Executor) (*matilda.TableDiff,
+    error) {
+	var td = new(matilda.TableDiff)
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		td.Table = p.table
+		exists, err := p.tableExists(ex)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver Diff: %w", err)
+		}
+		if exists == false {
+			td.Missing = true
+			return td, nil
+		}
+
+		// Columns
+		dbcols, err := p.dbColumns(ex)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver Diff: %w", err)
+		}
+		byName := make(map[string]*pgColumn)
+		for _, dbcol := range dbcols {
+			byName[dbcol.name] = dbcol
+		}
+		for _, col := range p.table.AllColumns {
+			dbcol, ok := byName[col.Name]
+			if ok == false {
+				td.MissingColumns = append(td.MissingColumns,
+				    col)
+				continue
+			}
+			delete(byName, col.Name)
+			cd, err := p.diffColumn(col, dbcol)
+			if err != nil {
+				return nil, err
+			}
+			if cd != nil {
+				td.ChangedColumns = append(td.ChangedColumns,
+				    cd)
+			}
+		}
+		for _, dbcol := range dbcols {
+			if _, ok := byName[dbcol.name]; ok == true {
+				td.ExtraColumns = append(td.ExtraColumns,
+				    dbcol.name)
+			}
+		}
+
+		// Indexes
+		dbidxs, err := p.dbIndexes(ex)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver Diff: %w", err)
+		}
+		found := make(map[string]*pgIndex)
+		for _, dbidx := range dbidxs {
+			found[dbidx.name] = dbidx
+		}
+		for _, idx := range p.table.Indexes {
+			if idx.GetType() == matilda.PKEY {
+				continue
+			}
+			dbidx, ok := found[idx.GetName()]
+			switch {
+			case ok == false:
+				td.MissingIndexes = append(td.MissingIndexes,
+				    idx)
+			case indexChanged(idx, dbidx) == true:
+				td.ChangedIndexes = append(td.ChangedIndexes,
+				    idx)
+			}
+			delete(found, idx.GetName())
+		}
+		for _, dbidx := range dbidxs {
+			if _, ok := found[dbidx.name]; ok == true {
+				td.ExtraIndexes = append(td.ExtraIndexes,
+				    dbidx.name)
+			}
+		}
+
+		// Constraints, added with their columns when missing
+		dbcons, err := p.dbConstraints(ex)
+		if err != nil {
+			return nil, fmt.Errorf("matilda driver Diff: %w", err)
+		}
+		missing := make(map[*matilda.Column]bool)
+		for _, col := range td.MissingColumns {
+			missing[col] = true
+		}
+		for _, col := range p.table.AllColumns {
+			cons, err := p.constraints(col)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range cons {
+				def, ok := dbcons[c.Name]
+				delete(dbcons, c.Name)
+				switch {
+				case missing[col] == true:
+				case ok == false:
+					td.MissingConstraints = append(
+					    td.MissingConstraints, c)
+				case normalizeExpr(c.Def) != normalizeExpr(def):
+					td.ChangedConstraints = append(
+					    td.ChangedConstraints, c)
+				}
+			}
+		}
+		for name := range dbcons {
+			td.ExtraConstraints = append(td.ExtraConstraints, name)
+		}
+		sort.Strings(td.ExtraConstraints)
+		return td, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (p *PgSchemaDriver) alterColumn(cd *matilda.ColumnDiff) (
+    stmts []string, err error) {
+
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ",
+	    assureIdentifier(p.table.Name), assureIdentifier(cd.Column.Name))
+
+	if cd.TypChanged == true {
+		typ, err := p.columnTyp(cd.Column)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, fmt.Sprintf("%sTYPE %s USING %s::%s;",
+		    alter, typ, assureIdentifier(cd.Column.Name), typ))
+	}
+	if cd.NotNullChanged == true {
+		if cd.DBNotNull == true {
+			stmts = append(stmts, alter + "DROP NOT NULL;")
+		} else {
+			stmts = append(stmts, alter + "SET NOT NULL;")
+		}
+	}
+	if cd.DefaultChanged == true {
+		if val := cd.Column.DefaultValue(); val != nil {
+			lit, err := sqlLiteral(val)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, alter + "SET DEFAULT " + lit +
+			    ";")
+		} else {
+			stmts = append(stmts, alter + "DROP DEFAULT;")
+		}
+	}
+	return stmts, nil
+}
+
+func (p *PgSchemaDriver) AlterTable(td *matilda.TableDiff) ([]string,
+    error) {
+	var stmts []string
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		if td.Missing == true {
+			return p.CreateTable()
+		}
+		table := assureIdentifier(p.table.Name)
+		alter := "ALTER TABLE " + table + " "
+
+		for _, col := range td.MissingColumns {
+			def, err := p.columnDef(col)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, alter + "ADD COLUMN " + def + ";")
+		}
+
+		// Dropped only on request, columns lose their data
+		if td.DropExtra == true {
+			for _, name := range td.ExtraConstraints {
+				stmts = append(stmts, alter +
+				    "DROP CONSTRAINT " +
+				    assureIdentifier(name) + ";")
+			}
+			for _, name := range td.ExtraColumns {
+				stmts = append(stmts, alter + "DROP COLUMN " +
+				    assureIdentifier(name) + ";")
+			}
+			for _, name := range td.ExtraIndexes {
+				stmts = append(stmts, "DROP INDEX " +
+				    assureIdentifier(name) + ";")
+			}
+		}
+
+		for _, cd := range td.ChangedColumns {
+			cstmts, err := p.alterColumn(cd)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, cstmts...)
+		}
+		for _, c := range td.ChangedConstraints {
+			stmts = append(stmts, alter + "DROP CONSTRAINT " +
+			    assureIdentifier(c.Name) + ";")
+		}
+		for _, cons := range [][]*matilda.Constraint{
+		    td.ChangedConstraints, td.MissingConstraints} {
+			for _, c := range cons {
+				stmts = append(stmts, alter +
+				    "ADD CONSTRAINT " +
+				    assureIdentifier(c.Name) + " " + c.Def +
+				    ";")
+			}
+		}
+		for _, idx := range td.ChangedIndexes {
+			sql, err := p.DropIndex(idx, false)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, sql)
+		}
+		for _, idxs := range [][]*matilda.Index{td.ChangedIndexes,
+		    td.MissingIndexes} {
+			for _, idx := range idxs {
+				sql, err := p.CreateIndex(idx, false)
+				if err != nil {
+					return nil, err
+				}
+				stmts = append(stmts, sql)
+			}
+		}
+		return stmts, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+func TestNormalizeExpr(t *testing.T) {
+	var tests = []struct {
+		declared string
+		db string
+		equal bool
+	}{
+		{`CHECK ("age" BETWEEN 0 AND 10)`,
+		    `CHECK (age >= 0 AND age <= 10)`, true},
+		{`CHECK ("age" BETWEEN 0 AND 10)`,
+		    `CHECK (((age >= 0) AND (age <= 10)))`, true},
+		{`CHECK ("age" >= 1)`, `CHECK (age >= 0)`, false},
+		{`CHECK (octet_length("name") >= 3)`,
+		    `CHECK (octet_length(name::text) >= 3)`, true},
+		{`CHECK (octet_length("name") >= 3)`,
+		    `CHECK (octet_length((name)::character varying) >= 3)`,
+		    true},
+		{`FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ` +
+		    `ON DELETE CASCADE`, `FOREIGN KEY (owner_id) ` +
+		    `REFERENCES users(id) ON DELETE CASCADE`, true},
+		{`FOREIGN KEY ("owner_id") REFERENCES "users" ("id")`,
+		    `FOREIGN KEY (owner_id) REFERENCES users(id) ` +
+		    `ON DELETE CASCADE`, false},
+		{`"a","b" DESC`, `a,b DESC`, true},
+		{`"a","b"`, `a,b DESC`, false},
+		{`status = 'new'`, `status = 'new'::text`, true},
+		{`name::text and x`, `name and x`, true},
+		{`deleted_at IS NULL`, ``, false},
+	}
+
+	for _, tt := range tests {
+		equal := normalizeExpr(tt.declared) == normalizeExpr(tt.db)
+		if equal != tt.equal {
+			t.Errorf("%s and %s: got equal %v", tt.declared, tt.db,
+			    equal)
+		}
+	}
+}
+
+func TestIndexChanged(t *testing.T) {
+	var dbidx = &pgIndex{name: "items_name_idx", cols: "name, created DESC",
+	    where: "(deleted IS NULL)"}
+	var tests = []struct {
+		idx *matilda.Index
+		changed bool
+	}{
+		{matilda.NewIndex("items_name_idx", "name", "created").SetDesc(
+		    "created").SetWhere("deleted IS NULL"), false},
+		{matilda.NewIndexUnique("items_name_idx", "name",
+		    "created").SetDesc("created").SetWhere("deleted IS NULL"),
+		    true},
+		{matilda.NewIndex("items_name_idx", "name", "created").SetWhere(
+		    "deleted IS NULL"), true},
+		{matilda.NewIndex("items_name_idx", "name").SetWhere(
+		    "deleted IS NULL"), true},
+		{matilda.NewIndex("items_name_idx", "name", "created").SetDesc(
+		    "created"), true},
+	}
+
+	for i, tt := range tests {
+		if got := indexChanged(tt.idx, dbidx); got != tt.changed {
+			t.Errorf("%d: got changed %v, want %v", i, got,
+			    tt.changed)
+		}
+	}
+}
+
+func TestAlterTableDrops(t *testing.T) {
+
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("name", &matilda.VdrString{MinLen: 2}),
+	    matilda.NewCol("age", &matilda.VdrInt64{Min: 1}))
+	p := NewSchemaDriver(tb).(*PgSchemaDriver)
+	cons, err := p.constraints(tb.GetColumn("age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	td := &matilda.TableDiff{Table: tb,
+	    ExtraColumns: []string{"old"},
+	    ExtraIndexes: []string{"items_old_idx"},
+	    ExtraConstraints: []string{"items_old_check"},
+	    ChangedIndexes: []*matilda.Index{matilda.NewIndex("items_name_idx",
+	    "name")},
+	    ChangedConstraints: cons}
+
+	stmts, err := p.AlterTable(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`ALTER TABLE "items" DROP CONSTRAINT "items_age_check";`,
+		`ALTER TABLE "items" ADD CONSTRAINT "items_age_check" ` +
+		    `CHECK ("age" >= 1);`,
+		`DROP INDEX "items_name_idx";`,
+		`CREATE INDEX "items_name_idx" ON "items" ("name");`,
+	}
+	if strings.Join(stmts, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(stmts, "\n"),
+		    strings.Join(want, "\n"))
+	}
+
+	td.DropExtra = true
+	if stmts, err = p.AlterTable(td); err != nil {
+		t.Fatal(err)
+	}
+	want = append([]string{
+		`ALTER TABLE "items" DROP CONSTRAINT "items_old_check";`,
+		`ALTER TABLE "items" DROP COLUMN "old";`,
+		`DROP INDEX "items_old_idx";`,
+	}, want...)
+	if strings.Join(stmts, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(stmts, "\n"),
+		    strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,370 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/radixo/matilda"
+)
+
+func NewSchemaDriver(e matilda.Entity) matilda.Driver {
+	var d = new(PgSchemaDriver)
+
+	d.etype = e.GetType()
+
+	// Stores table reference into the driver instance
+	if d.etype == matilda.ENT_TABLE {
+		d.table = e.(*matilda.Table)
+	}
+	return d
+}
+
+// Features of the CRUD driver bound to the table, as COPY depends on it
+func (p *PgSchemaDriver) Capabilities() matilda.Capability {
+
+	if p.table != nil && p.table.GetDB() != nil {
+		return p.table.Capabilities()
+	}
+	return PgDialect{}.Capabilities()
+}
+
+func quoteLiteral(s string) string {
+
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// Render a Go value as a SQL literal
+func sqlLiteral(val interface{}) (string, error) {
+
+	switch v := val.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if v == true {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32,
+	    uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	case string:
+		return quoteLiteral(v), nil
+	case []byte:
+		return quoteLiteral(string(v)), nil
+	case fmt.Stringer:
+		return quoteLiteral(v.String()), nil
+	default:
+		return "", fmt.Errorf("matilda driver: Can't render %T as " +
+		    "literal.", v)
+	}
+}
+
+// Range check matching the Min and Max validator options
+func rangeCheck(ident string, min, max int64) string {
+
+	switch {
+	case min == 0 && max == 0:
+		return ""
+	case max > 0:
+		return fmt.Sprintf("%s BETWEEN %d AND %d", ident, min, max)
+	default:
+		return fmt.Sprintf("%s >= %d", ident, min)
+	}
+}
+
+// Check expressions derived from the column validators
+func vdrChecks(col *matilda.Column) (checks []string) {
+	var check string
+
+	ident := assureIdentifier(col.Name)
+	for _, vdr := range col.Validators {
+		switch v := vdr.(type) {
+		case *matilda.VdrInt64:
+			check = rangeCheck(ident, v.Min, v.Max)
+		case *matilda.VdrInt32:
+			check = rangeCheck(ident, int64(v.Min), int64(v.Max))
+		case *matilda.VdrString:
+			if v.MinLen > 0 && v.Password == false {
+				check = fmt.Sprintf("octet_length(%s) >= %d",
+				    ident, v.MinLen)
+			}
+		}
+		if check != "" {
+			checks = append(checks, check)
+			check = ""
+		}
+	}
+	return
+}
+
+func (p *PgSchemaDriver) columnTyp(col *matilda.Column) (string, error) {
+
+	typ := PgDialect{}.ColumnType(col)
+	if typ == "" {
+		return "", fmt.Errorf("matilda driver: Column %q has no type.",
+		    col.Name)
+	}
+	return typ, nil
+}
+
+var fkActions = map[matilda.FKAction]string{
+	matilda.FK_NO_ACTION: "NO ACTION",
+	matilda.FK_RESTRICT: "RESTRICT",
+	matilda.FK_CASCADE: "CASCADE",
+	matilda.FK_SET_NULL: "SET NULL",
+	matilda.FK_SET_DEFAULT: "SET DEFAULT",
+}
+
+// REFERENCES clause of a foreign key column
+func (p *PgSchemaDriver) foreignKey(col *matilda.Column) (string, error) {
+
+	if col.FKey.Table == nil || col.FKey.Table.GetColumn(
+	    col.FKey.Column) == nil {
+		return "", fmt.Errorf("matilda driver: Column %q references " +
+		    "an unknown column.", col.Name)
+	}
+	sql := fmt.Sprintf("REFERENCES %s (%s)",
+	    assureIdentifier(col.FKey.Table.Name),
+	    assureIdentifier(col.FKey.Column))
+	if col.FKey.OnDelete != matilda.FK_NO_ACTION {
+		sql += " ON DELETE " + fkActions[col.FKey.OnDelete]
+	}
+	if col.FKey.OnUpdate != matilda.FK_NO_ACTION {
+		sql += " ON UPDATE " + fkActions[col.FKey.OnUpdate]
+	}
+	return sql, nil
+}
+
+func (p *PgSchemaDriver) checkName(col *matilda.Column) string {
+
+	return p.table.Name + "_" + col.Name + "_check"
+}
+
+func (p *PgSchemaDriver) fkeyName(col *matilda.Column) string {
+
+	return p.table.Name + "_" + col.Name + "_fkey"
+}
+
+// CHECK and FOREIGN KEY constraints of col as table constraints
+func (p *PgSchemaDriver) constraints(col *matilda.Column) (
+    cons []*matilda.Constraint, err error) {
+
+	if checks := vdrChecks(col); len(checks) > 0 {
+		cons = append(cons, &matilda.Constraint{Name: p.checkName(col),
+		    Def: "CHECK (" + strings.Join(checks, " AND ") + ")"})
+	}
+	if col.FKey != nil {
+		ref, err := p.foreignKey(col)
+		if err != nil {
+			return nil, err
+		}
+		cons = append(cons, &matilda.Constraint{Name: p.fkeyName(col),
+		    Def: fmt.Sprintf("FOREIGN KEY (%s) %s",
+		    assureIdentifier(col.Name), ref)})
+	}
+	return cons, nil
+}
+
+func (p *PgSchemaDriver) columnDef(col *matilda.Column) (string, error) {
+	var def = []string{assureIdentifier(col.Name)}
+
+	typ, err := p.columnTyp(col)
+	if err != nil {
+		return "", err
+	}
+	def = append(def, typ)
+
+	if col.AutoInc == true {
+		switch strings.ToLower(typ) {
+		case "smallserial", "serial", "bigserial":
+		default:
+			def = append(def, "GENERATED BY DEFAULT AS IDENTITY")
+		}
+	}
+	if col.IsNotNull() == true {
+		def = append(def, "NOT NULL")
+	}
+	if val := col.DefaultValue(); val != nil && col.AutoInc == false {
+		lit, err := sqlLiteral(val)
+		if err != nil {
+			return "", err
+		}
+		def = append(def, "DEFAULT " + lit)
+	}
+	if checks := vdrChecks(col); len(checks) > 0 {
+		def = append(def, fmt.Sprintf("CONSTRAINT %s CHECK (%s)",
+		    assureIdentifier(p.checkName(col)),
+		    strings.Join(checks, " AND ")))
+	}
+	if col.FKey != nil {
+		ref, err := p.foreignKey(col)
+		if err != nil {
+			return "", err
+		}
+		def = append(def, "CONSTRAINT " +
+		    assureIdentifier(p.fkeyName(col)) + " " + ref)
+	}
+
+	return strings.Join(def, " "), nil
+}
+
+func (p *PgSchemaDriver) CreateTable() ([]string, error) {
+	var defs []string
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		if len(p.table.AllColumns) == 0 {
+			return nil, fmt.Errorf("matilda driver: Table %q has " +
+			    "no columns.", p.table.Name)
+		}
+		for _, col := range p.table.AllColumns {
+			def, err := p.columnDef(col)
+			if err != nil {
+				return nil, err
+			}
+			defs = append(defs, def)
+		}
+		if p_cols := p.pkeysIdentifiers(); len(p_cols) > 0 {
+			defs = append(defs, fmt.Sprintf(
+			    "CONSTRAINT %s PRIMARY KEY (%s)",
+			    assureIdentifier(p.table.Name + "_pkey"),
+			    strings.Join(p_cols, ",")))
+		}
+		sql := fmt.Sprintf("CREATE TABLE %s (\n\t%s\n);",
+		    assureIdentifier(p.table.Name),
+		    strings.Join(defs, ",\n\t"))
+		stmts := []string{sql}
+
+		// Declared indexes
+		for _, idx := range p.table.Indexes {
+			if idx.GetType() == matilda.PKEY {
+				continue
+			}
+			sql, err := p.CreateIndex(idx, false)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, sql)
+		}
+		return stmts, nil
+	default:
+		return nil, errors.New("Entity type not implemented.")
+	}
+}
+
+func (p *PgSchemaDriver) DropTable() (string, error) {
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		return fmt.Sprintf("DROP TABLE %s;",
+		    assureIdentifier(p.table.Name)), nil
+	default:
+		return "", errors.New("Entity type not implemented.")
+	}
+}
+
+func (p *PgSchemaDriver) TruncateTable(others ...*matilda.Table) (string,
+    error) {
+	var names []string
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		names = append(names, assureIdentifier(p.table.Name))
+		for _, t := range others {
+			names = append(names, assureIdentifier(t.Name))
+		}
+		return fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY;",
+		    strings.Join(names, ",")), nil
+	default:
+		return "", errors.New("Entity type not implemented.")
+	}
+}
+
+func (p *PgSchemaDriver) pkeysIdentifiers() (cols []string) {
+
+	for _, col := range p.table.PKeys {
+		cols = append(cols, assureIdentifier(col.Name))
+	}
+	return
+}
+
+func (p *PgSchemaDriver) hasColumn(name string) bool {
+
+	for _, col := range p.table.AllColumns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PgSchemaDriver) CreateIndex(idx *matilda.Index,
+    concurrently bool) (string, error) {
+	var cols []string
+	var sql = "CREATE "
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		switch idx.GetType() {
+		case matilda.PKEY:
+			return "", fmt.Errorf("matilda driver: Index %q is a " +
+			    "primary key, it is created with the table.",
+			    idx.GetName())
+		case matilda.UNIQ:
+			sql += "UNIQUE "
+		}
+		sql += "INDEX "
+		if concurrently == true {
+			sql += "CONCURRENTLY "
+		}
+
+		for i, col := range idx.GetColumns() {
+			if p.hasColumn(col) == false {
+				return "", fmt.Errorf("matilda driver: Index " +
+				    "%q column %q not found.", idx.GetName(),
+				    col)
+			}
+			if idx.IsAsc(i) == true {
+				cols = append(cols, assureIdentifier(col))
+			} else {
+				cols = append(cols, assureIdentifier(col) +
+				    " DESC")
+			}
+		}
+		if len(cols) == 0 {
+			return "", fmt.Errorf("matilda driver: Index %q has " +
+			    "no columns.", idx.GetName())
+		}
+
+		sql += fmt.Sprintf("%s ON %s (%s)",
+		    assureIdentifier(idx.GetName()),
+		    assureIdentifier(p.table.Name), strings.Join(cols, ","))
+		if idx.GetWhere() != "" {
+			sql += " WHERE " + idx.GetWhere()
+		}
+		return sql + ";", nil
+	default:
+		return "", errors.New("Entity type not implemented.")
+	}
+}
+
+func (p *PgSchemaDriver) DropIndex(idx *matilda.Index,
+    concurrently bool) (string, error) {
+	var sql = "DROP INDEX "
+
+	switch p.etype {
+	case matilda.ENT_TABLE:
+		if idx.GetType() == matilda.PKEY {
+			return "", fmt.Errorf("matilda driver: Index %q is a " +
+			    "primary key, it is dropped with the table.",
+			    idx.GetName())
+		}
+		if concurrently == true {
+			sql += "CONCURRENTLY "
+		}
+		return sql + assureIdentifier(idx.GetName()) + ";", nil
+	default:
+		return "", errors.New("Entity type not implemented.")
+	}
+}
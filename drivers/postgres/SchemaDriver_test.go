@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+func TestColumnDef(t *testing.T) {
+	var tests = []struct {
+		col *matilda.Column
+		def string
+	}{
+		{matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+		    `"id" bigint GENERATED BY DEFAULT AS IDENTITY`},
+		{matilda.NewColAutoInc("n").SetTyp("bigserial"),
+		    `"n" bigserial`},
+		{matilda.NewCol("name", &matilda.VdrString{NotNull: true,
+		    MaxLen: 20}), `"name" varchar(20) NOT NULL`},
+		{matilda.NewCol("status", &matilda.VdrString{Default: "it's"}),
+		    `"status" text DEFAULT 'it''s'`},
+		{matilda.NewCol("active", &matilda.VdrBool{NotNull: true,
+		    Default: true}), `"active" boolean NOT NULL DEFAULT TRUE`},
+		{matilda.NewCol("n", &matilda.VdrInt32{Default: 3}),
+		    `"n" integer DEFAULT 3`},
+		{matilda.NewCol("ref", &matilda.VdrUID{}), `"ref" uuid`},
+		{matilda.NewCol("age", &matilda.VdrInt64{Min: 1, Max: 9}),
+		    `"age" bigint CONSTRAINT "items_age_check" ` +
+		    `CHECK ("age" BETWEEN 1 AND 9)`},
+	}
+
+	tb := matilda.NewTable(nil, nil, "items")
+	p := NewSchemaDriver(tb).(*PgSchemaDriver)
+	for _, tt := range tests {
+		def, err := p.columnDef(tt.col)
+		if err != nil {
+			t.Errorf("%s: %v", tt.col.Name, err)
+			continue
+		}
+		if def != tt.def {
+			t.Errorf("got %s, want %s", def, tt.def)
+		}
+	}
+
+	if _, err := p.columnDef(matilda.NewCol("x")); err == nil {
+		t.Error("column without type rendered")
+	}
+}
+
+func TestCreateTable(t *testing.T) {
+
+	users := matilda.NewTable(nil, nil, "users",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}))
+	tb := matilda.NewTable(nil, nil, "members",
+	    matilda.NewColPK("group_id", &matilda.VdrInt64{}),
+	    matilda.NewColPK("user_id", &matilda.VdrInt64{}).SetFKey(users,
+	    "id", matilda.FK_CASCADE, matilda.FK_NO_ACTION),
+	    matilda.NewCol("role", &matilda.VdrString{NotNull: true}))
+	tb.AddIndex("members_role_idx", "role")
+
+	stmts, err := NewSchemaDriver(tb).(*PgSchemaDriver).CreateTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"CREATE TABLE \"members\" (\n" +
+		    "\t\"group_id\" bigint,\n" +
+		    "\t\"user_id\" bigint CONSTRAINT " +
+		    "\"members_user_id_fkey\" REFERENCES \"users\" (\"id\") " +
+		    "ON DELETE CASCADE,\n" +
+		    "\t\"role\" text NOT NULL,\n" +
+		    "\tCONSTRAINT \"members_pkey\" PRIMARY KEY " +
+		    "(\"group_id\",\"user_id\")\n);",
+		`CREATE INDEX "members_role_idx" ON "members" ("role");`,
+	}
+	if strings.Join(stmts, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(stmts, "\n"),
+		    strings.Join(want, "\n"))
+	}
+
+	empty := matilda.NewTable(nil, nil, "empty")
+	if _, err = NewSchemaDriver(empty).(*PgSchemaDriver).CreateTable();
+	    err == nil {
+		t.Fatal("table without columns rendered")
+	}
+}
+
+func TestIndexes(t *testing.T) {
+	var tests = []struct {
+		idx *matilda.Index
+		concurrently bool
+		create, drop string
+	}{
+		{matilda.NewIndex("items_name_idx", "name"), false,
+		    `CREATE INDEX "items_name_idx" ON "items" ("name");`,
+		    `DROP INDEX "items_name_idx";`},
+		{matilda.NewIndexUnique("items_name_key", "name", "created"),
+		    true, `CREATE UNIQUE INDEX CONCURRENTLY "items_name_key" ` +
+		    `ON "items" ("name","created");`,
+		    `DROP INDEX CONCURRENTLY "items_name_key";`},
+		{matilda.NewIndex("items_created_idx", "created",
+		    "name").SetDesc("created").SetWhere("deleted IS NULL"),
+		    false, `CREATE INDEX "items_created_idx" ON "items" ` +
+		    `("created" DESC,"name") WHERE deleted IS NULL;`,
+		    `DROP INDEX "items_created_idx";`},
+	}
+
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("name", &matilda.VdrString{}),
+	    matilda.NewCol("created", &matilda.VdrInt64{}))
+	p := NewSchemaDriver(tb).(*PgSchemaDriver)
+	for _, tt := range tests {
+		create, err := p.CreateIndex(tt.idx, tt.concurrently)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if create != tt.create {
+			t.Errorf("got %s, want %s", create, tt.create)
+		}
+		drop, err := p.DropIndex(tt.idx, tt.concurrently)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if drop != tt.drop {
+			t.Errorf("got %s, want %s", drop, tt.drop)
+		}
+	}
+
+	_, err := p.CreateIndex(matilda.NewIndex("items_x_idx", "x"), false)
+	if err == nil {
+		t.Error("index on an unknown column rendered")
+	}
+}
+
+func TestVdrChecks(t *testing.T) {
+	var tests = []struct {
+		vdr matilda.FieldValidator
+		check string
+	}{
+		{&matilda.VdrInt64{}, ""},
+		{&matilda.VdrInt64{Min: 1}, `"v" >= 1`},
+		{&matilda.VdrInt64{Min: -5}, `"v" >= -5`},
+		{&matilda.VdrInt64{Max: 9}, `"v" BETWEEN 0 AND 9`},
+		{&matilda.VdrInt64{Min: 1, Max: 9}, `"v" BETWEEN 1 AND 9`},
+		{&matilda.VdrInt32{Min: 2, Max: 4}, `"v" BETWEEN 2 AND 4`},
+		{&matilda.VdrString{MinLen: 3}, `octet_length("v") >= 3`},
+		{&matilda.VdrString{MinLen: 3, Password: true}, ""},
+		{&matilda.VdrString{MaxLen: 3}, ""},
+	}
+
+	for _, tt := range tests {
+		checks := vdrChecks(matilda.NewCol("v", tt.vdr))
+		if got := strings.Join(checks, " AND "); got != tt.check {
+			t.Errorf("%#v: got %q, want %q", tt.vdr, got, tt.check)
+		}
+	}
+}
+
+func TestColumnTypes(t *testing.T) {
+	var tests = []struct {
+		vdr matilda.FieldValidator
+		typ string
+	}{
+		{&matilda.VdrString{MaxLen: 20}, "varchar(20)"},
+		{&matilda.VdrString{MinLen: 2}, "text"},
+		{&matilda.VdrString{MaxLen: 20, Password: true}, "text"},
+		{&matilda.VdrInt64{}, "bigint"},
+		{&matilda.VdrInt32{}, "integer"},
+		{&matilda.VdrUID{}, "uuid"},
+	}
+
+	for _, tt := range tests {
+		got := PgDialect{}.ColumnType(matilda.NewCol("v", tt.vdr))
+		if got != tt.typ {
+			t.Errorf("%#v: got %s, want %s", tt.vdr, got, tt.typ)
+		}
+	}
+}
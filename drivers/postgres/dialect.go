@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// Postgres SQL dialect
+type PgDialect struct {
+	drivers.BaseDialect
+
+	// Library supports COPY FROM STDIN
+	CopyIn bool
+}
+
+func assureIdentifier(s string) string {
+
+	return drivers.BaseDialect{}.Quote(s)
+}
+
+// Value stored as json or jsonb
+//
+// Only map[string]interface{} values are sent as json implicitly, slices
+// become arrays whatever their element type.
+type JSON struct {
+	V interface{}
+}
+
+func (j JSON) Value() (driver.Value, error) {
+
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func quoteElem(s string) string {
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) +
+	    `"`
+}
+
+// Postgres array literal of a slice, nested slices are sub arrays
+func arrayLiteral(rv reflect.Value) string {
+	var elems []string
+
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		if elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if elem.IsValid() == false || (elem.Kind() == reflect.Ptr &&
+		    elem.IsNil() == true) {
+			elems = append(elems, "NULL")
+			continue
+		}
+		switch v := elem.Interface().(type) {
+		case string:
+			elems = append(elems, quoteElem(v))
+		case bool:
+			if v == true {
+				elems = append(elems, "t")
+			} else {
+				elems = append(elems, "f")
+			}
+		case time.Time:
+			elems = append(elems,
+			    quoteElem(v.Format(time.RFC3339Nano)))
+		case *big.Float:
+			elems = append(elems, v.Text('f', -1))
+		case fmt.Stringer:
+			elems = append(elems, quoteElem(v.String()))
+		default:
+			if elem.Kind() == reflect.Slice {
+				elems = append(elems, arrayLiteral(elem))
+			} else {
+				elems = append(elems, fmt.Sprint(v))
+			}
+		}
+	}
+	return "{" + strings.Join(elems, ",") + "}"
+}
+
+// Convert values to types accepted by both lib/pq and pgx
+func assureVal(val interface{}) interface{} {
+
+	switch v := val.(type) {
+	case matilda.UID:
+		return v.String()
+	case map[string]interface{}:
+		// json and jsonb
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+		return val
+	case *big.Int, *big.Float, *big.Rat:
+		// numeric
+		if reflect.ValueOf(v).IsNil() == true {
+			return nil
+		}
+		switch n := v.(type) {
+		case *big.Rat:
+			return n.FloatString(32)
+		case *big.Float:
+			return n.Text('f', -1)
+		}
+		return v.(fmt.Stringer).String()
+	case []byte, time.Time, driver.Valuer:
+		return val
+	}
+
+	// Arrays
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Slice {
+		if rv.IsNil() == true {
+			return nil
+		}
+		return arrayLiteral(rv)
+	}
+	return val
+}
+
+func (d PgDialect) Quote(s string) string {
+
+	return assureIdentifier(s)
+}
+
+func (d PgDialect) Placeholder(n int) string {
+
+	return "$" + strconv.Itoa(n)
+}
+
+func (d PgDialect) Value(val interface{}) interface{} {
+
+	return assureVal(val)
+}
+
+func (d PgDialect) ColumnType(col *matilda.Column) string {
+
+	if col.Typ == "" {
+		for _, vdr := range col.Validators {
+			if _, ok := vdr.(*matilda.VdrUID); ok == true {
+				return "uuid"
+			}
+		}
+	}
+	return d.BaseDialect.ColumnType(col)
+}
+
+func (d PgDialect) Capabilities() matilda.Capability {
+	var c = matilda.CAP_RETURNING | matilda.CAP_UPSERT |
+	    matilda.CAP_SAVEPOINT | matilda.CAP_ROW_LOCKING |
+	    matilda.CAP_LISTEN_NOTIFY | matilda.CAP_CURSORS
+
+	// COPY FROM STDIN goes through lib/pq CopyIn statements only
+	if d.CopyIn == true {
+		c |= matilda.CAP_COPY
+	}
+	return c
+}
+
+func (d PgDialect) CopyFrom(table string, cols []string) string {
+
+	if d.CopyIn == false {
+		return ""
+	}
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN", table,
+	    strings.Join(cols, ", "))
+}
+
+// Bind messages count parameters in 16 bits
+func (d PgDialect) MaxParams() int {
+
+	return 65535
+}
+
+func (d PgDialect) OnConflict(conflict, update, params []string,
+    pkey bool) string {
+	var set []string
+
+	sql := "ON CONFLICT (" + strings.Join(conflict, ",") + ")"
+	if len(update) == 0 {
+		return sql + " DO NOTHING"
+	}
+	for i, col := range update {
+		set = append(set, col + " = " + params[i])
+	}
+	return sql + " DO UPDATE SET " + strings.Join(set, ",")
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+type stringer string
+
+func (s stringer) String() string {
+
+	return string(s)
+}
+
+func TestAssureVal(t *testing.T) {
+	var f, _ = new(big.Float).SetString("12345678901.25")
+	var tm = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var tests = []struct {
+		name string
+		val interface{}
+		want interface{}
+	}{
+		{"big.Float", f, "12345678901.25"},
+		{"big.Int", big.NewInt(-42), "-42"},
+		{"big.Rat", big.NewRat(1, 4),
+		    "0.25000000000000000000000000000000"},
+		{"nil big.Float", (*big.Float)(nil), nil},
+		{"map", map[string]interface{}{"a": 1}, `{"a":1}`},
+		{"JSON", JSON{[]int{1, 2}}, JSON{[]int{1, 2}}},
+		{"nil slice", []string(nil), nil},
+		{"strings", []string{"a", `b"c`, `d\e`},
+		    `{"a","b\"c","d\\e"}`},
+		{"int64s", []int64{1, -2}, "{1,-2}"},
+		{"interfaces", []interface{}{"a", 1, nil, true},
+		    `{"a",1,NULL,t}`},
+		{"nested", [][]int{{1, 2}, {3, 4}}, "{{1,2},{3,4}}"},
+		{"nested interfaces", []interface{}{[]string{"a"},
+		    []string{"b"}}, `{{"a"},{"b"}}`},
+		{"stringers", []stringer{`x"y`, `z\`}, `{"x\"y","z\\"}`},
+		{"floats", []*big.Float{f, nil}, "{12345678901.25,NULL}"},
+		{"times", []time.Time{tm}, `{"2020-01-02T03:04:05Z"}`},
+	}
+
+	for _, tt := range tests {
+		got := assureVal(tt.val)
+		if j, ok := tt.want.(JSON); ok == true {
+			want, _ := j.Value()
+			got, _ = got.(JSON).Value()
+			if got != want {
+				t.Errorf("%s: got %v, want %v", tt.name, got,
+				    want)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAssureIdentifier(t *testing.T) {
+	var tests = map[string]string{
+		"name": `"name"`,
+		`a"b`: `"a""b"`,
+		`a\b`: `"a\b"`,
+		`"quoted"`: `"quoted"`,
+	}
+
+	for in, want := range tests {
+		if got := assureIdentifier(in); got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+
+	want := "RETURNING, upsert, savepoints, row locking, LISTEN/NOTIFY, " +
+	    "server-side cursors"
+	if got := (PgDialect{}).Capabilities().String(); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	tb := matilda.NewTable(nil, nil, "items")
+	if got := NewSchemaDriver(tb).Capabilities().String(); got != want {
+		t.Fatalf("unbound table: got %s, want %s", got, want)
+	}
+}
+
+// Executor recording the last statement
+type recorder struct {
+	sql string
+	args []interface{}
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string,
+    args ...interface{}) (sql.Result, error) {
+
+	r.sql, r.args = query, args
+	return driver.RowsAffected(2), nil
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string,
+    args ...interface{}) (*sql.Rows, error) {
+
+	return nil, errors.New("not recorded")
+}
+
+func (r *recorder) QueryRowContext(ctx context.Context, query string,
+    args ...interface{}) *sql.Row {
+
+	return nil
+}
+
+func (r *recorder) PrepareContext(ctx context.Context, query string) (
+    *sql.Stmt, error) {
+
+	return nil, errors.New("not recorded")
+}
+
+func TestUpdateWhereParams(t *testing.T) {
+	var r = new(recorder)
+
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("name", &matilda.VdrString{}),
+	    matilda.NewCol("age", &matilda.VdrInt64{}))
+	d := drivers.NewSqlCRUDDriver(PgDialect{})(tb).(matilda.CRUDDriver)
+
+	// Filters are numbered first, as in Select
+	n, err := d.UpdateWhere(context.Background(), r,
+	    map[string]interface{}{"name": "b", "age": int64(3)},
+	    `"age" > $1 AND "name" <> $2`, int64(1), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "items" SET "name" = $3,"age" = $4 ` +
+	    `WHERE "age" > $1 AND "name" <> $2;`
+	if n != 2 || r.sql != want {
+		t.Fatalf("got %d rows of\n%s\nwant\n%s", n, r.sql, want)
+	}
+	args := []interface{}{int64(1), "x", "b", int64(3)}
+	if reflect.DeepEqual(r.args, args) == false {
+		t.Fatalf("got args %v, want %v", r.args, args)
+	}
+}
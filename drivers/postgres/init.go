@@ -3,16 +3,30 @@ package postgres
 import (
 
 	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
 )
 
+// Driver types, and sql.Register names for explicit selection
 var knownDrivers = []string {
 	"*pq.Driver",
+	"*stdlib.Driver",
+	"postgres",
+	"pgx",
+}
+
+// Drivers supporting COPY FROM STDIN
+var copyDrivers = map[string]bool {
+	"*pq.Driver": true,
+	"postgres": true,
 }
 
 func init() {
 
 	// Drivers registration
 	for _, dname := range knownDrivers {
-		matilda.RegisterCRUDDriver(dname, NewCRUDDriver)
+		dialect := PgDialect{CopyIn: copyDrivers[dname]}
+		matilda.RegisterCRUDDriver(dname,
+		    drivers.NewSqlCRUDDriver(dialect))
+		matilda.RegisterSchemaDriver(dname, NewSchemaDriver)
 	}
 }
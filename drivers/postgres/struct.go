@@ -5,7 +5,7 @@ import (
 	"github.com/radixo/matilda"
 )
 
-type PgCRUDDriver struct {
+type PgSchemaDriver struct {
 	// Entity type
 	etype matilda.EntityType
 
@@ -11,6 +11,9 @@ type sqlRows struct {
 	entity matilda.Entity
 	cols []string
 	fieldValidators matilda.FieldValidatorsRunner
+
+	// Conversion of scanned values, optional
+	scanned func(map[string]interface{})
 }
 
 func getAutoIncColumn(e matilda.Entity) *matilda.Column {
@@ -116,6 +119,9 @@ func (r *sqlRows) Tuple() (map[string]interface{}, error) {
 	for i := range r.cols {
 		ret[r.cols[i]] = _data[i]
 	}
+	if r.scanned != nil {
+		r.scanned(ret)
+	}
 
 	if r.fieldValidators != nil {
 		// Validate each field ignoring errors
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// SQLite SQL dialect
+type SqliteDialect struct {
+	drivers.BaseDialect
+}
+
+// RETURNING needs SQLite 3.35, it reads back the row an upsert updated
+func (d SqliteDialect) Capabilities() matilda.Capability {
+
+	return matilda.CAP_RETURNING | matilda.CAP_UPSERT |
+	    matilda.CAP_SAVEPOINT
+}
+
+func (d SqliteDialect) OnConflict(conflict, update, params []string,
+    pkey bool) string {
+	var set []string
+
+	sql := "ON CONFLICT (" + strings.Join(conflict, ",") + ")"
+	if len(update) == 0 {
+		return sql + " DO NOTHING"
+	}
+	for i, col := range update {
+		set = append(set, col + " = " + params[i])
+	}
+	return sql + " DO UPDATE SET " + strings.Join(set, ",")
+}
@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers"
+)
+
+// Driver types, and sql.Register names for explicit selection
+var knownDrivers = []string {
+	"*sqlite3.SQLiteDriver",
+	"*sqlite.Driver",
+	"sqlite3",
+	"sqlite",
+}
+
+func init() {
+
+	// Drivers registration
+	for _, dname := range knownDrivers {
+		matilda.RegisterCRUDDriver(dname,
+		    drivers.NewSqlCRUDDriver(SqliteDialect{}))
+	}
+}
@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/radixo/matilda"
+	_ "modernc.org/sqlite"
+)
+
+func openItems(t *testing.T) (*sql.DB, *matilda.Table) {
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err = db.Exec(`CREATE TABLE "items" (` +
+	    `"id" INTEGER PRIMARY KEY AUTOINCREMENT, ` +
+	    `"name" TEXT NOT NULL UNIQUE, "note" TEXT DEFAULT 'none', ` +
+	    `"updated" INTEGER);`); err != nil {
+		t.Fatal(err)
+	}
+	tb := matilda.NewTable(nil, db, "items",
+	    matilda.NewColAutoIncPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("name", &matilda.VdrString{}),
+	    matilda.NewCol("note", &matilda.VdrString{}),
+	    matilda.NewCol("updated", &matilda.VdrInt64{UpdateUnixNow: true}))
+	return db, tb
+}
+
+func count(t *testing.T, db *sql.DB) (n int) {
+
+	if err := db.QueryRow(`SELECT count(*) FROM "items";`).Scan(
+	    &n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestInsertManyAtomic(t *testing.T) {
+
+	db, tb := openItems(t)
+	rows := []map[string]interface{}{
+		{"name": "a"},
+		{"name": "b", "note": "x"},
+		{"name": "a", "note": "y"},
+	}
+	if _, err := tb.InsertMany(rows); err == nil {
+		t.Fatal("duplicate name inserted")
+	}
+	if n := count(t, db); n != 0 {
+		t.Fatalf("got %d rows after a failed InsertMany, want 0", n)
+	}
+}
+
+func TestInsertManyBatches(t *testing.T) {
+	var rows []map[string]interface{}
+
+	db, tb := openItems(t)
+	n := SqliteDialect{}.MaxParams() * 2 + 1
+	for i := 0; i < n; i++ {
+		rows = append(rows, map[string]interface{}{
+		    "name": fmt.Sprint("item", i)})
+	}
+	if _, err := tb.InsertMany(rows); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, db); got != n {
+		t.Fatalf("got %d rows, want %d", got, n)
+	}
+}
+
+func TestInsertNull(t *testing.T) {
+
+	_, tb := openItems(t)
+	tests := map[string]map[string]interface{}{
+		"none": {"name": "a"},
+		"": {"name": "b", "note": nil},
+	}
+	for note, data := range tests {
+		if err := tb.Insert(data); err != nil {
+			t.Fatal(err)
+		}
+		row, err := tb.SelectOne(nil, `"name" = ?`, data["name"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want interface{}
+		if note != "" {
+			want = note
+		}
+		if row["note"] != want || data["note"] != want {
+			t.Errorf("%s: got note %v, read back %v, want %v",
+			    data["name"], row["note"], data["note"], want)
+		}
+	}
+}
+
+func TestUpsertKeys(t *testing.T) {
+
+	_, tb := openItems(t)
+	for _, name := range []string{"a", "b"} {
+		err := tb.Insert(map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := map[string]interface{}{"id": 1, "name": "a2"}
+	if err := tb.Upsert(data, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data["id"] != int64(1) {
+		t.Fatalf("got id %v, want the given 1", data["id"])
+	}
+
+	// Keys of updated and inserted rows are read back
+	opts := &matilda.UpsertOpts{Conflict: []string{"name"}}
+	for _, name := range []string{"b", "c"} {
+		data = map[string]interface{}{"name": name, "note": "x"}
+		if err := tb.Upsert(data, opts); err != nil {
+			t.Fatal(err)
+		}
+		row, err := tb.SelectOne(nil, `"name" = ?`, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data["id"] != row["id"] {
+			t.Errorf("%s: got id %v, want %v", name, data["id"],
+			    row["id"])
+		}
+	}
+}
+
+func TestUpdateDeleteWhere(t *testing.T) {
+
+	db, tb := openItems(t)
+	for _, name := range []string{"a", "b", "c"} {
+		err := tb.Insert(map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Set values come before the filter params with ? placeholders
+	set := map[string]interface{}{"note": "n"}
+	n, err := tb.UpdateWhere(set, `"name" <> ? AND "id" < ?`, "b", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || set[matilda.RES_ROWSAFFECTED] != int64(1) {
+		t.Fatalf("got %d rows, %v affected", n,
+		    set[matilda.RES_ROWSAFFECTED])
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		row, err := tb.SelectOne(nil, `"name" = ?`, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated := row["note"] == "n" && row["updated"] != nil
+		if updated != (name == "a") {
+			t.Errorf("%s: got %v", name, row)
+		}
+	}
+
+	n, err = tb.DeleteWhere(`"note" = 'none' AND "name" <> ?`, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || count(t, db) != 2 {
+		t.Fatalf("got %d deleted, %d left", n, count(t, db))
+	}
+	if n, err = tb.DeleteWhere(""); err != nil || n != 2 {
+		t.Fatalf("got %d deleted, %v", n, err)
+	}
+}
@@ -0,0 +1,109 @@
+package matilda_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers/memory"
+)
+
+// Database library without matilda drivers
+type unknownDriver struct{}
+
+func (unknownDriver) Open(name string) (driver.Conn, error) {
+
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+
+	sql.Register("matilda-test-unknown", unknownDriver{})
+}
+
+func openDB(t *testing.T, driverName string) *sql.DB {
+
+	db, err := sql.Open(driverName, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		memory.Reset(t.Name())
+	})
+	return db
+}
+
+func TestSetDBDriverConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+
+	db := openDB(t, "matilda-test-unknown")
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				matilda.SetDBDriver(db, memory.DriverName)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				matilda.OpenTable(nil, db, "items")
+			}
+		}()
+	}
+	wg.Wait()
+
+	matilda.SetDBDriver(db, "")
+	if _, err := matilda.OpenTable(nil, db, "items"); err == nil {
+		t.Fatal("table bound without a driver")
+	}
+}
+
+func TestTableBindDBKeepsBinding(t *testing.T) {
+
+	db := openDB(t, memory.DriverName)
+	tb := matilda.NewTable(nil, db, "items", matilda.NewColAutoIncPK("id"))
+	if err := tb.BindDB(openDB(t, "matilda-test-unknown")); err == nil {
+		t.Fatal("table bound without a driver")
+	}
+	if tb.GetDB() != db {
+		t.Fatal("failed bind changed the table database")
+	}
+	if err := tb.SetDriverName("unknown"); err == nil {
+		t.Fatal("table bound to an unknown driver")
+	}
+	if tb.GetDriverName() != "" {
+		t.Fatal("failed bind changed the table driver name")
+	}
+	if err := tb.Insert(map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaBindDBAtomic(t *testing.T) {
+
+	db := openDB(t, memory.DriverName)
+	s := matilda.NewSchema(db)
+	named := s.NewTable(nil, "named", matilda.NewColAutoIncPK("id"))
+	if err := named.SetDriverName(memory.DriverName); err != nil {
+		t.Fatal(err)
+	}
+	detected := s.NewTable(nil, "detected", matilda.NewColAutoIncPK("id"))
+
+	if err := s.BindDB(openDB(t, "matilda-test-unknown")); err == nil {
+		t.Fatal("schema bound without a driver")
+	}
+	for _, tb := range []*matilda.Table{named, detected} {
+		if tb.GetDB() != db {
+			t.Fatalf("table %q rebound by a failed bind", tb.Name)
+		}
+	}
+	if s.GetDB() != db {
+		t.Fatal("failed bind changed the schema database")
+	}
+}
@@ -0,0 +1,41 @@
+package matilda
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Statements runner, satisfied by *sql.DB, *sql.Tx and *sql.Conn
+//
+// A *sql.Conn pins work to one connection without a transaction, e.g. for
+// session settings or advisory locks.
+type Executor interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result,
+	    error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows,
+	    error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+}
+
+// Executor of ex, db when ex is nil or a nil pointer
+func assureExecutor(ex Executor, db *sql.DB) Executor {
+
+	switch v := ex.(type) {
+	case nil:
+		return db
+	case *sql.Tx:
+		if v == nil {
+			return db
+		}
+	case *sql.Conn:
+		if v == nil {
+			return db
+		}
+	case *sql.DB:
+		if v == nil {
+			return db
+		}
+	}
+	return ex
+}
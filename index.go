@@ -5,6 +5,7 @@ type IdxType uint
 const (
 	PKEY IdxType = iota
 	INDX
+	UNIQ
 )
 
 type Index struct {
@@ -19,4 +20,82 @@ type Index struct {
 
 	// Index column asc
 	asc map[int]bool
+
+	// Partial index predicate
+	where string
+}
+
+func newIndex(name string, typ IdxType, cols ...string) (idx *Index) {
+
+	idx = new(Index)
+	idx.name = name
+	idx.typ = typ
+	idx.columns = make(map[int]string)
+	idx.asc = make(map[int]bool)
+	for i, col := range cols {
+		idx.columns[i] = col
+		idx.asc[i] = true
+	}
+
+	return idx
+}
+
+func NewIndex(name string, cols ...string) (idx *Index) {
+
+	return newIndex(name, INDX, cols...)
+}
+
+func NewIndexUnique(name string, cols ...string) (idx *Index) {
+
+	return newIndex(name, UNIQ, cols...)
+}
+
+// Set the given index columns in descending order
+func (idx *Index) SetDesc(cols ...string) *Index {
+
+	for i, col := range idx.columns {
+		for _, dcol := range cols {
+			if col == dcol {
+				idx.asc[i] = false
+			}
+		}
+	}
+	return idx
+}
+
+// Set the predicate of a partial index
+func (idx *Index) SetWhere(pred string) *Index {
+
+	idx.where = pred
+	return idx
+}
+
+func (idx *Index) GetName() string {
+
+	return idx.name
+}
+
+func (idx *Index) GetType() IdxType {
+
+	return idx.typ
+}
+
+// Index columns in index order
+func (idx *Index) GetColumns() (cols []string) {
+
+	for i := 0; i < len(idx.columns); i++ {
+		cols = append(cols, idx.columns[i])
+	}
+	return
+}
+
+// Is the column at position i in ascending order
+func (idx *Index) IsAsc(i int) bool {
+
+	return idx.asc[i]
+}
+
+func (idx *Index) GetWhere() string {
+
+	return idx.where
 }
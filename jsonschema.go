@@ -0,0 +1,178 @@
+package matilda
+
+import (
+	"encoding/json"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// FieldValidators describing their rules as JSON Schema
+type FieldSchemer interface {
+	// Set the rules on the column property, returning if it is required
+	FieldSchema(map[string]interface{}, DataState) bool
+}
+
+// Set the property type, allowing null when the field is nullable
+func schemaType(prop map[string]interface{}, notNull bool,
+    typs ...string) {
+	var t []string
+
+	t = append(t, typs...)
+	if notNull == false {
+		t = append(t, "null")
+	}
+	if len(t) == 1 {
+		prop["type"] = t[0]
+	} else {
+		prop["type"] = t
+	}
+}
+
+// Set minimum and maximum as checked by the int validators
+func schemaRange(prop map[string]interface{}, min, max int64) {
+
+	if min == 0 && max == 0 {
+		return
+	}
+	prop["minimum"] = min
+	if max > 0 {
+		prop["maximum"] = max
+	}
+}
+
+func (vi64 *VdrInt64) FieldSchema(prop map[string]interface{},
+    ds DataState) bool {
+
+	if vi64.Timestamp == true {
+		schemaType(prop, vi64.NotNull, "integer", "string")
+	} else {
+		schemaType(prop, vi64.NotNull, "integer")
+	}
+	schemaRange(prop, vi64.Min, vi64.Max)
+	if vi64.Default != nil {
+		prop["default"] = vi64.Default
+	}
+
+	// Values set by the validator
+	if (vi64.InsertUnixNow == true && ds == DS_INSERT) ||
+	    (vi64.UpdateUnixNow == true && ds == DS_UPDATE) {
+		prop["readOnly"] = true
+		return false
+	}
+	return vi64.NotNull == true && vi64.Default == nil
+}
+
+func (vi32 *VdrInt32) FieldSchema(prop map[string]interface{},
+    ds DataState) bool {
+
+	schemaType(prop, vi32.NotNull, "integer")
+	schemaRange(prop, int64(vi32.Min), int64(vi32.Max))
+	if vi32.Default != nil {
+		prop["default"] = vi32.Default
+	}
+	return vi32.NotNull == true && vi32.Default == nil
+}
+
+func (vstr *VdrString) FieldSchema(prop map[string]interface{},
+    ds DataState) bool {
+
+	schemaType(prop, vstr.NotNull, "string")
+	if vstr.Default != nil {
+		prop["default"] = vstr.Default
+	}
+	if vstr.Password == true {
+		prop["writeOnly"] = true
+	}
+
+	// Lengths are only checked on insert and update. They count bytes,
+	// JSON Schema counts characters of up to 4 bytes, so the bounds hold
+	// for any text and the validator has the last word
+	if ds == DS_INSERT || ds == DS_UPDATE {
+		if vstr.MinLen > 0 {
+			prop["minLength"] = (vstr.MinLen + 3) / 4
+		}
+		if vstr.MaxLen > 0 {
+			prop["maxLength"] = vstr.MaxLen
+		}
+	}
+	return vstr.NotNull == true && vstr.Default == nil
+}
+
+func (vboo *VdrBool) FieldSchema(prop map[string]interface{},
+    ds DataState) bool {
+
+	schemaType(prop, vboo.NotNull, "boolean")
+	if vboo.Default != nil {
+		prop["default"] = vboo.Default
+	}
+	return vboo.NotNull == true && vboo.Default == nil
+}
+
+func (vea *VdrEmailAddress) FieldSchema(prop map[string]interface{},
+    ds DataState) bool {
+
+	schemaType(prop, vea.NotNull, "string")
+	prop["format"] = "email"
+	return vea.NotNull == true
+}
+
+func (vuid *VdrUID) FieldSchema(prop map[string]interface{},
+    ds DataState) bool {
+
+	schemaType(prop, vuid.NotNull, "string")
+	// Hex digits, dashes are ignored
+	prop["pattern"] = "^-*([0-9a-fA-F]-*){32}$"
+	switch v := vuid.Default.(type) {
+	case nil:
+	case UID:
+		prop["default"] = v.String()
+	case *UID:
+		prop["default"] = v.String()
+	default:
+		prop["default"] = v
+	}
+	return vuid.NotNull == true && vuid.Default == nil &&
+	    vuid.AutoGen == false
+}
+
+// JSON Schema of table rows on the given DataState
+func (t *Table) JSONSchema(ds DataState) ([]byte, error) {
+	var props = make(map[string]interface{})
+	var required []string
+
+	for _, col := range t.AllColumns {
+		var req bool
+
+		prop := make(map[string]interface{})
+		for _, vdr := range col.Validators {
+			if schemer, ok := vdr.(FieldSchemer); ok == true {
+				req = schemer.FieldSchema(prop, ds) || req
+			}
+		}
+
+		switch {
+		case col.AutoInc == true && ds == DS_INSERT:
+			// Set by the database
+			req = false
+		case ds == DS_UPDATE:
+			// Missing fields are merged from database
+			req = col.PKey
+		}
+		if req == true {
+			required = append(required, col.Name)
+		}
+		props[col.Name] = prop
+	}
+
+	doc := map[string]interface{}{
+		"$schema": jsonSchemaDraft,
+		"title": t.Name,
+		"type": "object",
+		"properties": props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		doc["required"] = required
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,55 @@
+package matilda_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/radixo/matilda"
+)
+
+func schemaProps(t *testing.T, tb *matilda.Table,
+    ds matilda.DataState) map[string]map[string]interface{} {
+	var doc struct {
+		Properties map[string]map[string]interface{}
+	}
+
+	b, err := tb.JSONSchema(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc.Properties
+}
+
+func TestJSONSchemaUIDDefault(t *testing.T) {
+
+	uid := matilda.NewUID()
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewColPK("id", &matilda.VdrUID{Default: uid}),
+	    matilda.NewCol("ref", &matilda.VdrUID{Default: &uid}))
+	props := schemaProps(t, tb, matilda.DS_INSERT)
+	for _, name := range []string{"id", "ref"} {
+		if got := props[name]["default"]; got != uid.String() {
+			t.Errorf("%s: got default %v, want %s", name, got,
+			    uid.String())
+		}
+	}
+}
+
+func TestJSONSchemaLengths(t *testing.T) {
+
+	tb := matilda.NewTable(nil, nil, "items",
+	    matilda.NewCol("name", &matilda.VdrString{MinLen: 9, MaxLen: 20}))
+	prop := schemaProps(t, tb, matilda.DS_INSERT)["name"]
+
+	// Any text valid by bytes must be valid by characters
+	if prop["minLength"] != float64(3) || prop["maxLength"] != float64(20) {
+		t.Fatalf("got %v", prop)
+	}
+	prop = schemaProps(t, tb, matilda.DS_LOADED)["name"]
+	if prop["minLength"] != nil {
+		t.Fatalf("got %v on loaded rows", prop)
+	}
+}
@@ -9,3 +9,14 @@ func NewTable(parent interface{}, db *sql.DB, name string, cols ...*Column) (
 
 	return newTable(parent, db, name, cols...)
 }
+
+// Create a table returning an error when no driver is found for db
+func OpenTable(parent interface{}, db *sql.DB, name string,
+    cols ...*Column) (*Table, error) {
+
+	t := newTable(parent, nil, name, cols...)
+	if err := t.BindDB(db); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
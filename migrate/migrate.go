@@ -0,0 +1,361 @@
+// Package migrate runs versioned migrations on Postgres databases, keeping
+// the applied ones in the matilda_migrations table.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Default key for pg_advisory_lock
+const DefaultLockKey int64 = 0x6d6174696c6461
+
+const createTableSQL = `CREATE TABLE IF NOT EXISTS matilda_migrations (
+	version bigint NOT NULL PRIMARY KEY,
+	name text NOT NULL,
+	checksum text NOT NULL,
+	applied_at timestamp with time zone NOT NULL DEFAULT now()
+);`
+
+const tableExistsSQL = `SELECT to_regclass('matilda_migrations') IS NOT NULL;`
+
+type Migration struct {
+	// Migration version, applied in ascending order
+	Version int64
+
+	// Migration name
+	Name string
+
+	// Go functions, used when set
+	Up, Down func(*sql.Tx) error
+
+	// Checksum of Go functions, required with them as their code can't
+	// be hashed; change it along with Up, as a revision number
+	Sum string
+
+	// SQL statements, used when functions are not set
+	UpSQL, DownSQL string
+}
+
+// Checksum of SQL migrations is taken from UpSQL, functions use Sum
+func (mig *Migration) Checksum() string {
+	var sum [sha256.Size]byte
+
+	if mig.Up != nil {
+		sum = sha256.Sum256([]byte("func:" + mig.Sum))
+	} else {
+		sum = sha256.Sum256([]byte(mig.UpSQL))
+	}
+	return hex.EncodeToString(sum[:])
+}
+
+func (mig *Migration) up(tx *sql.Tx) error {
+
+	if mig.Up != nil {
+		return mig.Up(tx)
+	}
+	_, err := tx.Exec(mig.UpSQL)
+	return err
+}
+
+func (mig *Migration) down(tx *sql.Tx) error {
+
+	if mig.Down != nil {
+		return mig.Down(tx)
+	}
+	if mig.DownSQL == "" {
+		return fmt.Errorf("matilda migrate: Migration %d has no down.",
+		    mig.Version)
+	}
+	_, err := tx.Exec(mig.DownSQL)
+	return err
+}
+
+type Status struct {
+	Version int64
+	Name string
+
+	// Applied on database
+	Applied bool
+	AppliedAt time.Time
+
+	// Registered migration differs from the applied one
+	Changed bool
+
+	// Applied on database but not registered
+	Unknown bool
+}
+
+type Migrator struct {
+	// Database connection, the same used by matilda.Table
+	db *sql.DB
+
+	// Registered migrations
+	migrations []*Migration
+
+	// Key for pg_advisory_lock serializing concurrent runners
+	LockKey int64
+}
+
+// Executors of the bookkeeping queries, *sql.Conn and *sql.DB
+type queryer interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows,
+	    error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// Applied migration as stored on database
+type applied struct {
+	name string
+	checksum string
+	at time.Time
+}
+
+func New(db *sql.DB) (m *Migrator) {
+
+	m = new(Migrator)
+	m.db = db
+	m.LockKey = DefaultLockKey
+
+	return m
+}
+
+// Register a migration written as Go functions, sum stands for their code
+// in the checksum
+func (m *Migrator) Add(version int64, name, sum string, up,
+    down func(*sql.Tx) error) {
+
+	m.migrations = append(m.migrations, &Migration{Version: version,
+	    Name: name, Up: up, Down: down, Sum: sum})
+}
+
+// Register a migration written as SQL
+func (m *Migrator) AddSQL(version int64, name string, up, down string) {
+
+	m.migrations = append(m.migrations, &Migration{Version: version,
+	    Name: name, UpSQL: up, DownSQL: down})
+}
+
+// Registered migrations by version
+func (m *Migrator) sorted() ([]*Migration, error) {
+
+	migs := make([]*Migration, len(m.migrations))
+	copy(migs, m.migrations)
+	sort.Slice(migs, func(i, j int) bool {
+		return migs[i].Version < migs[j].Version
+	})
+	for i, mig := range migs {
+		if i > 0 && mig.Version == migs[i-1].Version {
+			return nil, fmt.Errorf("matilda migrate: Version %d " +
+			    "registered twice.", mig.Version)
+		}
+		if mig.Up != nil && mig.Sum == "" {
+			return nil, fmt.Errorf("matilda migrate: Migration " +
+			    "%d has no Sum.", mig.Version)
+		}
+	}
+	return migs, nil
+}
+
+// Run f holding the advisory lock on a single connection
+func (m *Migrator) locked(f func(*sql.Conn) error) (err error) {
+	var ctx = context.Background()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1);",
+	    m.LockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, uerr := conn.ExecContext(ctx,
+		    "SELECT pg_advisory_unlock($1);", m.LockKey)
+		if err == nil {
+			err = uerr
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, createTableSQL); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+func (m *Migrator) applied(q queryer) (map[int64]*applied, error) {
+	var ret = make(map[int64]*applied)
+
+	rows, err := q.QueryContext(context.Background(),
+	    "SELECT version, name, checksum, applied_at " +
+	    "FROM matilda_migrations;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		a := new(applied)
+		if err = rows.Scan(&version, &a.name, &a.checksum,
+		    &a.at); err != nil {
+			return nil, err
+		}
+		ret[version] = a
+	}
+	return ret, rows.Err()
+}
+
+// Run f and the bookkeeping statement in a single transaction
+func runTx(conn *sql.Conn, f func(*sql.Tx) error, stmt string,
+    params ...interface{}) error {
+
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(stmt, params...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Apply all pending migrations
+func (m *Migrator) Up() error {
+
+	migs, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	return m.locked(func(conn *sql.Conn) error {
+		done, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		// Applied migrations must not change
+		for _, mig := range migs {
+			a, ok := done[mig.Version]
+			if ok == true && a.checksum != mig.Checksum() {
+				return fmt.Errorf("matilda migrate: " +
+				    "Migration %d %q changed after being " +
+				    "applied.",
+				    mig.Version, mig.Name)
+			}
+		}
+
+		for _, mig := range migs {
+			if _, ok := done[mig.Version]; ok == true {
+				continue
+			}
+			if err = runTx(conn, mig.up,
+			    "INSERT INTO matilda_migrations" +
+			    "(version,name,checksum)VALUES($1,$2,$3);",
+			    mig.Version, mig.Name, mig.Checksum());
+			    err != nil {
+				return fmt.Errorf("matilda migrate: Up %d " +
+				    "%q: %v", mig.Version, mig.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Revert the last applied migration
+func (m *Migrator) Down() error {
+
+	migs, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	return m.locked(func(conn *sql.Conn) error {
+		var last *Migration
+
+		done, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			return nil
+		}
+
+		var version int64
+		first := true
+		for v := range done {
+			if first == true || v > version {
+				version = v
+				first = false
+			}
+		}
+		for _, mig := range migs {
+			if mig.Version == version {
+				last = mig
+			}
+		}
+		if last == nil {
+			return fmt.Errorf("matilda migrate: Applied " +
+			    "migration %d is not registered.", version)
+		}
+
+		if err = runTx(conn, last.down,
+		    "DELETE FROM matilda_migrations WHERE version = $1;",
+		    last.Version); err != nil {
+			return fmt.Errorf("matilda migrate: Down %d %q: %v",
+			    last.Version, last.Name, err)
+		}
+		return nil
+	})
+}
+
+// Registered and applied migrations by version, read without locking or
+// creating the migrations table
+func (m *Migrator) Status() (status []*Status, err error) {
+	var exists bool
+	var done = make(map[int64]*applied)
+
+	migs, err := m.sorted()
+	if err != nil {
+		return nil, err
+	}
+	err = m.db.QueryRowContext(context.Background(),
+	    tableExistsSQL).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists == true {
+		if done, err = m.applied(m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, mig := range migs {
+		s := &Status{Version: mig.Version, Name: mig.Name}
+		if a, ok := done[mig.Version]; ok == true {
+			s.Applied = true
+			s.AppliedAt = a.at
+			s.Changed = a.checksum != mig.Checksum()
+			delete(done, mig.Version)
+		}
+		status = append(status, s)
+	}
+	for version, a := range done {
+		status = append(status, &Status{Version: version,
+		    Name: a.name, Applied: true, AppliedAt: a.at,
+		    Unknown: true})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Version < status[j].Version
+	})
+	return status, nil
+}
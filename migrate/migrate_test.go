@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func noop(*sql.Tx) error {
+
+	return nil
+}
+
+func TestChecksum(t *testing.T) {
+
+	m := New(nil)
+	m.Add(1, "one", "r1", noop, noop)
+	m.Add(2, "two", "r1", noop, noop)
+	m.AddSQL(3, "three", "CREATE TABLE a ();", "")
+	migs, err := m.sorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migs[0].Checksum() != migs[1].Checksum() {
+		t.Fatal("checksum of functions depends on the name")
+	}
+	sum := migs[0].Checksum()
+	migs[0].Sum = "r2"
+	if migs[0].Checksum() == sum {
+		t.Fatal("checksum ignores Sum")
+	}
+	sum = migs[2].Checksum()
+	migs[2].UpSQL = "CREATE TABLE b ();"
+	if migs[2].Checksum() == sum {
+		t.Fatal("checksum ignores UpSQL")
+	}
+}
+
+func TestSorted(t *testing.T) {
+
+	m := New(nil)
+	m.Add(1, "one", "", noop, noop)
+	if _, err := m.sorted(); err == nil {
+		t.Fatal("function migration accepted without Sum")
+	}
+
+	m = New(nil)
+	m.AddSQL(2, "two", "", "")
+	m.AddSQL(1, "one", "", "")
+	m.AddSQL(2, "again", "", "")
+	if _, err := m.sorted(); err == nil {
+		t.Fatal("version accepted twice")
+	}
+}
@@ -0,0 +1,243 @@
+package matilda
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Set of tables of an application
+type Schema struct {
+	// Database connection
+	db *sql.DB
+
+	// Registered tables
+	tables []*Table
+}
+
+func NewSchema(db *sql.DB) (s *Schema) {
+
+	s = new(Schema)
+	s.db = db
+
+	return s
+}
+
+// Create a table bound to the schema database and register it
+func (s *Schema) NewTable(parent interface{}, name string,
+    cols ...*Column) (t *Table) {
+
+	t = newTable(parent, s.db, name, cols...)
+	s.Add(t)
+
+	return t
+}
+
+// Register tables on schema
+func (s *Schema) Add(tables ...*Table) {
+
+	s.tables = append(s.tables, tables...)
+}
+
+func (s *Schema) GetTable(name string) *Table {
+
+	for _, t := range s.tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Schema) GetDB() *sql.DB {
+
+	return s.db
+}
+
+// Bind the schema and all of its tables to db, panics when no driver is
+// found
+func (s *Schema) SetDB(db *sql.DB) {
+
+	if err := s.BindDB(db); err != nil {
+		panic(err)
+	}
+}
+
+// Bind the schema and all of its tables to db, nothing is rebound when a
+// table has no driver
+func (s *Schema) BindDB(db *sql.DB) error {
+	var drvs = make([]CRUDDriver, len(s.tables))
+	var sdrvs = make([]SchemaDriver, len(s.tables))
+	var err error
+
+	for i, t := range s.tables {
+		if drvs[i], sdrvs[i], err = t.findDrivers(db); err != nil {
+			return err
+		}
+	}
+	s.db = db
+	for i, t := range s.tables {
+		t.db, t.drv, t.sdrv = db, drvs[i], sdrvs[i]
+	}
+	return nil
+}
+
+// Tables in dependency order, referenced tables first
+func (s *Schema) Tables() (tables []*Table, err error) {
+	var done = make(map[*Table]bool)
+	var visiting = make(map[*Table]bool)
+	var visit func(*Table) error
+
+	registered := make(map[*Table]bool)
+	for _, t := range s.tables {
+		registered[t] = true
+	}
+
+	visit = func(t *Table) error {
+		if done[t] == true {
+			return nil
+		}
+		if visiting[t] == true {
+			return fmt.Errorf("matilda: Foreign key cycle on " +
+			    "table %q.", t.Name)
+		}
+		visiting[t] = true
+		for _, ref := range t.References() {
+			// Tables out of the schema are not managed by it
+			if registered[ref] == false {
+				continue
+			}
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		visiting[t] = false
+		done[t] = true
+		tables = append(tables, t)
+		return nil
+	}
+
+	for _, t := range s.tables {
+		if err = visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// Registered tables referencing t
+func (s *Schema) ReferencedBy(t *Table) (tables []*Table) {
+
+	for _, st := range s.tables {
+		for _, ref := range st.References() {
+			if ref == t {
+				tables = append(tables, st)
+				break
+			}
+		}
+	}
+	return
+}
+
+func (s *Schema) execStmts(ex Executor, stmts []string) error {
+
+	ex = assureExecutor(ex, s.db)
+	for _, stmt := range stmts {
+		_, err := ex.ExecContext(context.Background(), stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) Create() error {
+
+	return s.CreateTx(nil)
+}
+
+func (s *Schema) CreateTx(ex Executor) error {
+
+	tables, err := s.Tables()
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		if err = t.CreateTableTx(ex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) Drop() error {
+
+	return s.DropTx(nil)
+}
+
+func (s *Schema) DropTx(ex Executor) error {
+
+	tables, err := s.Tables()
+	if err != nil {
+		return err
+	}
+	for i := len(tables) - 1; i >= 0; i-- {
+		if err = tables[i].DropTableTx(ex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) Truncate() error {
+
+	return s.TruncateTx(nil)
+}
+
+// Empty all tables at once, as tables referenced by others can't be
+// emptied alone
+func (s *Schema) TruncateTx(ex Executor) error {
+
+	tables, err := s.Tables()
+	if err != nil || len(tables) == 0 {
+		return err
+	}
+	sdrv, err := tables[0].schemaDriver()
+	if err != nil {
+		return err
+	}
+	stmt, err := sdrv.TruncateTable(tables[1:]...)
+	if err != nil {
+		return err
+	}
+	return s.execStmts(ex, []string{stmt})
+}
+
+// Compare all tables with the database
+func (s *Schema) Diff() (*SchemaDiff, error) {
+
+	tables, err := s.Tables()
+	if err != nil {
+		return nil, err
+	}
+	return Diff(tables...)
+}
+
+// Check that the database matches all tables
+func (s *Schema) Verify() error {
+	var names []string
+
+	sd, err := s.Diff()
+	if err != nil {
+		return err
+	}
+	if sd.IsEmpty() == true {
+		return nil
+	}
+	for _, td := range sd.Tables {
+		names = append(names, td.Table.Name)
+	}
+	return fmt.Errorf("matilda: Database differs from schema on " +
+	    "tables: %s.", strings.Join(names, ", "))
+}
@@ -1,6 +1,7 @@
 package matilda
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
@@ -21,11 +22,20 @@ type Table struct {
 	// Table primary keys
 	PKeys []*Column
 
+	// Table indexes
+	Indexes []*Index
+
 	// Database connection
 	db *sql.DB
 
+	// Registered driver name, chosen by database when empty
+	drvName string
+
 	// Database driver
 	drv CRUDDriver
+
+	// Database schema driver, nil when not available
+	sdrv SchemaDriver
 }
 
 func newTable(parent interface{}, db *sql.DB, name string, cols ...*Column) (
@@ -52,6 +62,50 @@ func (t *Table) addCol(col *Column) {
 	t.AllColumns = append(t.AllColumns, col)
 }
 
+func (t *Table) GetColumn(name string) *Column {
+
+	for _, col := range t.AllColumns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// Tables referenced by foreign keys, except the table itself
+func (t *Table) References() (tables []*Table) {
+
+	_loop:
+	for _, col := range t.AllColumns {
+		if col.FKey == nil || col.FKey.Table == t {
+			continue
+		}
+		for _, ref := range tables {
+			if ref == col.FKey.Table {
+				continue _loop
+			}
+		}
+		tables = append(tables, col.FKey.Table)
+	}
+	return
+}
+
+func (t *Table) addIndex(idx *Index) *Index {
+
+	t.Indexes = append(t.Indexes, idx)
+	return idx
+}
+
+func (t *Table) GetIndex(name string) *Index {
+
+	for _, idx := range t.Indexes {
+		if idx.name == name {
+			return idx
+		}
+	}
+	return nil
+}
+
 func (t *Table) GetType() EntityType {
 
 	return ENT_TABLE
@@ -62,23 +116,95 @@ func (t *Table) GetDB() *sql.DB {
 	return t.db
 }
 
+// Bind the table to db, panics when no driver is found
 func (t *Table) SetDB(db *sql.DB) {
-	var err error
+
+	if err := t.BindDB(db); err != nil {
+		panic(err)
+	}
+}
+
+// Bind the table to db, keeping the current binding on errors
+func (t *Table) BindDB(db *sql.DB) error {
+
+	drv, sdrv, err := t.findDrivers(db)
+	if err != nil {
+		return err
+	}
+	t.db, t.drv, t.sdrv = db, drv, sdrv
+	return nil
+}
+
+// Drivers of the table on db, none when db is nil
+func (t *Table) findDrivers(db *sql.DB) (drv CRUDDriver, sdrv SchemaDriver,
+    err error) {
 
 	if db == nil {
-		t.db = nil
-		t.drv = nil
-		return
+		return nil, nil, nil
 	}
+	d, err := findDriver(crudDrivers, t.drvName, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	drv = d(t).(CRUDDriver)
 
-	t.db = db
-	if t.drv, err = GetCRUDDriver(t); err != nil {
-		panic(err)
+	// Schema support is optional
+	if d, err := findDriver(schemaDrivers, t.drvName, db); err == nil {
+		sdrv = d(t).(SchemaDriver)
 	}
-	return
+	return drv, sdrv, nil
+}
+
+func (t *Table) GetDriverName() string {
+
+	return t.drvName
+}
+
+// Use the driver registered as name, rebinding the table database
+func (t *Table) SetDriverName(name string) error {
+
+	old := t.drvName
+	t.drvName = name
+	if err := t.BindDB(t.db); err != nil {
+		t.drvName = old
+		return err
+	}
+	return nil
+}
+
+// Features of the table driver, none when not bound
+func (t *Table) Capabilities() Capability {
+
+	if t.drv == nil {
+		return 0
+	}
+	return t.drv.(Driver).Capabilities()
+}
+
+// Report if the table driver supports all features in c
+func (t *Table) Supports(c Capability) bool {
+
+	return t.drv != nil && t.Capabilities() & c == c
 }
 
-func (t *Table) mergeWithDB(data map[string]interface{}) error {
+// Fail with ErrUnsupported when the table driver lacks features in c
+func (t *Table) require(c Capability) error {
+
+	if t.Supports(c) == false {
+		return fmt.Errorf("%w Table %q driver lacks %s.",
+		    ErrUnsupported, t.Name, c)
+	}
+	return nil
+}
+
+// Executor running the table statements, its database when ex is nil
+func (t *Table) executor(ex Executor) Executor {
+
+	return assureExecutor(ex, t.db)
+}
+
+func (t *Table) mergeWithDB(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
 	var keys []interface{}
 	var cols []string
 
@@ -101,7 +227,7 @@ func (t *Table) mergeWithDB(data map[string]interface{}) error {
 		return nil
 	}
 
-	rows, err := t.SelectByKey(cols, keys...)
+	rows, err := t.SelectByKeyCtx(ctx, ex, cols, keys...)
 	if err != nil {
 		return err
 	}
@@ -120,9 +246,25 @@ type FieldValidatorsRunner interface {
 	RunFieldValidators(map[string]interface{}, DataState) error
 }
 
+// Run a field validator, with the context when it takes one
+func validateField(ctx context.Context, vdr FieldValidator,
+    data map[string]interface{}, name string, ds DataState) error {
+
+	if v, ok := vdr.(FieldValidatorCtx); ok == true {
+		return v.ValidateFieldCtx(ctx, data, name, ds)
+	}
+	return vdr.ValidateField(data, name, ds)
+}
+
 func (t *Table) RunFieldValidators(data map[string]interface{},
     ds DataState) error {
 
+	return t.RunFieldValidatorsCtx(context.Background(), data, ds)
+}
+
+func (t *Table) RunFieldValidatorsCtx(ctx context.Context,
+    data map[string]interface{}, ds DataState) error {
+
 	// Validate each field
 	for _, col := range t.AllColumns {
 		if _, ok := data[col.Name]; ok == false && ds == DS_LOADED {
@@ -130,8 +272,8 @@ func (t *Table) RunFieldValidators(data map[string]interface{},
 		}
 
 		for _, vdr := range col.Validators {
-			if err := vdr.ValidateField(data, col.Name, ds);
-			    err != nil {
+			err := validateField(ctx, vdr, data, col.Name, ds)
+			if err != nil {
 				return err
 			}
 		}
@@ -141,23 +283,109 @@ func (t *Table) RunFieldValidators(data map[string]interface{},
 
 func (t *Table) RunValidators(data map[string]interface{}, ds DataState) error {
 
+	return t.RunValidatorsCtx(context.Background(), nil, data, ds)
+}
+
+// Run validators, updates read missing columns through ex
+func (t *Table) RunValidatorsCtx(ctx context.Context, ex Executor,
+    data map[string]interface{}, ds DataState) error {
+
 	// Merge with db version
 	if ds == DS_UPDATE {
-		err := t.mergeWithDB(data)
+		err := t.mergeWithDB(ctx, ex, data)
 		if err != nil {
 			return err
 		}
 	}
 
 	// Validate each field
-	if err := t.RunFieldValidators(data, ds); err != nil {
+	if err := t.RunFieldValidatorsCtx(ctx, data, ds); err != nil {
 		return err
 	}
+	return t.runTableValidator(ctx, data, ds)
+}
+
+func (t *Table) runTableValidator(ctx context.Context,
+    data map[string]interface{}, ds DataState) error {
+
+	switch validator := t.parent.(type) {
+	case TableValidatorCtx:
+		return validator.ValidateCtx(ctx, data, ds)
+	case TableValidator:
+		return validator.Validate(data, ds)
+	}
+	return nil
+}
+
+// Validate a partial update with the parent PartialValidator, other table
+// validators get a copy of data merged with the database row and their
+// changes to the patched columns are kept
+func (t *Table) runPartialValidator(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
+	var row map[string]interface{}
 
-	if validator, ok := t.parent.(TableValidator); ok == true {
-		if err := validator.Validate(data, ds); err != nil {
+	merged := func() (map[string]interface{}, error) {
+		if row != nil {
+			return row, nil
+		}
+		m := make(map[string]interface{}, len(t.AllColumns))
+		for k, v := range data {
+			m[k] = v
+		}
+		if err := t.mergeWithDB(ctx, ex, m); err != nil {
+			return nil, err
+		}
+		row = m
+		return row, nil
+	}
+
+	switch validator := t.parent.(type) {
+	case PartialValidator:
+		return validator.ValidatePartial(ctx, data, merged)
+	case TableValidatorCtx, TableValidator:
+		m, err := merged()
+		if err != nil {
 			return err
 		}
+		if err = t.runTableValidator(ctx, m, DS_UPDATE); err != nil {
+			return err
+		}
+		for k := range data {
+			data[k] = m[k]
+		}
+	}
+	return nil
+}
+
+// Report if data has columns to update
+func (t *Table) hasColumns(data map[string]interface{}) bool {
+
+	for _, col := range t.Columns {
+		if _, ok := data[col.Name]; ok == true {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate the columns present in set for an update; absent columns only
+// get their update timestamps
+func (t *Table) runSetValidators(ctx context.Context,
+    set map[string]interface{}) error {
+
+	for _, col := range t.Columns {
+		_, ok := set[col.Name]
+		for _, vdr := range col.Validators {
+			v, is := vdr.(*VdrInt64)
+			if ok == false &&
+			    (is == false || v.UpdateUnixNow == false) {
+				continue
+			}
+			err := validateField(ctx, vdr, set, col.Name, DS_UPDATE)
+			if err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -167,12 +395,78 @@ func (t *Table) Insert(data map[string]interface{}) error {
 	return t.InsertTx(nil, data)
 }
 
-func (t *Table) InsertTx(tx *sql.Tx, data map[string]interface{}) error {
+func (t *Table) InsertTx(ex Executor, data map[string]interface{}) error {
 
-	if err := t.RunValidators(data, DS_INSERT); err != nil {
+	return t.InsertCtx(context.Background(), ex, data)
+}
+
+// Run insert validators, columns left out of data that validators leave nil
+// stay out so database defaults apply
+func (t *Table) runInsertValidators(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
+	var unset []string
+
+	for _, col := range t.AllColumns {
+		if _, ok := data[col.Name]; ok == false {
+			unset = append(unset, col.Name)
+		}
+	}
+	if err := t.RunValidatorsCtx(ctx, ex, data, DS_INSERT); err != nil {
 		return err
 	}
-	return t.drv.Insert(tx, data)
+	for _, name := range unset {
+		if data[name] == nil {
+			delete(data, name)
+		}
+	}
+	return nil
+}
+
+// Ctx variants take an optional Executor, and pass ctx to the database and
+// to FieldValidatorCtx and TableValidatorCtx validators
+//
+// A nil value in data is inserted as NULL, columns left out of data get
+// their database defaults.
+func (t *Table) InsertCtx(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
+
+	if err := t.runInsertValidators(ctx, ex, data); err != nil {
+		return err
+	}
+	return t.drv.Insert(ctx, t.executor(ex), data)
+}
+
+func (t *Table) InsertMany(rows []map[string]interface{}) (map[int]error,
+    error) {
+
+	return t.InsertManyTx(nil, rows)
+}
+
+func (t *Table) InsertManyTx(ex Executor, rows []map[string]interface{}) (
+    map[int]error, error) {
+
+	return t.InsertManyCtx(context.Background(), ex, rows)
+}
+
+// Insert the rows passing validators, failing ones are reported by index;
+// generated values are not read back
+func (t *Table) InsertManyCtx(ctx context.Context, ex Executor,
+    rows []map[string]interface{}) (map[int]error, error) {
+	var report = make(map[int]error)
+	var valid []map[string]interface{}
+
+	for i, data := range rows {
+		err := t.runInsertValidators(ctx, ex, data)
+		if err != nil {
+			report[i] = err
+			continue
+		}
+		valid = append(valid, data)
+	}
+	if len(valid) == 0 {
+		return report, nil
+	}
+	return report, t.drv.InsertMany(ctx, t.executor(ex), valid)
 }
 
 func (t *Table) Update(data map[string]interface{}) error {
@@ -180,12 +474,183 @@ func (t *Table) Update(data map[string]interface{}) error {
 	return t.UpdateTx(nil, data)
 }
 
-func (t *Table) UpdateTx(tx *sql.Tx, data map[string]interface{}) error {
+func (t *Table) UpdateTx(ex Executor, data map[string]interface{}) error {
+
+	return t.UpdateCtx(context.Background(), ex, data)
+}
+
+func (t *Table) UpdateCtx(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
 
-	if err := t.RunValidators(data, DS_UPDATE); err != nil {
+	if err := t.RunValidatorsCtx(ctx, ex, data, DS_UPDATE); err != nil {
 		return err
 	}
-	return t.drv.Update(tx, data)
+	return t.drv.Update(ctx, t.executor(ex), data)
+}
+
+func (t *Table) Patch(data map[string]interface{}) error {
+
+	return t.PatchTx(nil, data)
+}
+
+func (t *Table) PatchTx(ex Executor, data map[string]interface{}) error {
+
+	return t.PatchCtx(context.Background(), ex, data)
+}
+
+// Update only the columns present in data, without reading the row unless
+// a table validator needs it
+func (t *Table) PatchCtx(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
+
+	for _, col := range t.PKeys {
+		if _, ok := data[col.Name]; ok == false {
+			return fmt.Errorf("key %q not present in data.",
+			    col.Name)
+		}
+	}
+	if err := t.runSetValidators(ctx, data); err != nil {
+		return err
+	}
+	if t.hasColumns(data) == false {
+		return fmt.Errorf("matilda: No columns to update on table %q.",
+		    t.Name)
+	}
+	if err := t.runPartialValidator(ctx, ex, data); err != nil {
+		return err
+	}
+	if err := t.drv.Update(ctx, t.executor(ex), data); err != nil {
+		return err
+	}
+
+	if data[RES_ROWSAFFECTED] == int64(0) {
+		return fmt.Errorf("Record not found.")
+	}
+	return nil
+}
+
+func (t *Table) Upsert(data map[string]interface{}, opts *UpsertOpts) error {
+
+	return t.UpsertTx(nil, data, opts)
+}
+
+func (t *Table) UpsertTx(ex Executor, data map[string]interface{},
+    opts *UpsertOpts) error {
+
+	return t.UpsertCtx(context.Background(), ex, data, opts)
+}
+
+// Insert data or, on conflict, update the existing row; opts may be nil
+func (t *Table) UpsertCtx(ctx context.Context, ex Executor,
+    data map[string]interface{}, opts *UpsertOpts) error {
+	var o UpsertOpts
+	var raw = make(map[string]interface{}, len(data))
+
+	if err := t.require(CAP_UPSERT); err != nil {
+		return err
+	}
+
+	// Resolve defaults for the driver
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Conflict) == 0 {
+		for _, col := range t.PKeys {
+			o.Conflict = append(o.Conflict, col.Name)
+		}
+	}
+	if len(o.Conflict) == 0 {
+		return fmt.Errorf("matilda: Table %q has no conflict target.",
+		    t.Name)
+	}
+	if o.DoNothing == true {
+		o.Update = nil
+	}
+
+	// Given values, validated apart for the update
+	for k, v := range data {
+		raw[k] = v
+	}
+	if err := t.runInsertValidators(ctx, ex, data); err != nil {
+		return err
+	}
+	if o.DoNothing == true {
+		return t.drv.Upsert(ctx, t.executor(ex), data, &o)
+	}
+
+	set := make(map[string]interface{})
+	for _, col := range t.Columns {
+		if val, ok := raw[col.Name]; ok == true {
+			set[col.Name] = val
+		}
+	}
+	if err := t.runSetValidators(ctx, set); err != nil {
+		return err
+	}
+
+	// Update the given columns and the update timestamps by default
+	if o.Update == nil {
+		_loop:
+		for _, col := range t.Columns {
+			if _, ok := set[col.Name]; ok == false {
+				continue
+			}
+			for _, name := range o.Conflict {
+				if name == col.Name {
+					continue _loop
+				}
+			}
+			o.Update = append(o.Update, col.Name)
+		}
+	}
+	o.Values = make(map[string]interface{}, len(o.Update))
+	for _, name := range o.Update {
+		if val, ok := set[name]; ok == true {
+			o.Values[name] = val
+		}
+	}
+	return t.drv.Upsert(ctx, t.executor(ex), data, &o)
+}
+
+func (t *Table) UpdateWhere(set map[string]interface{}, filter string,
+    params ...interface{}) (int64, error) {
+
+	return t.UpdateWhereTx(nil, set, filter, params...)
+}
+
+func (t *Table) UpdateWhereTx(ex Executor, set map[string]interface{},
+    filter string, params ...interface{}) (int64, error) {
+
+	return t.UpdateWhereCtx(context.Background(), ex, set, filter,
+	    params...)
+}
+
+// Update the rows matching filter with set, without reading them; only set
+// columns are validated and TableValidator isn't run
+func (t *Table) UpdateWhereCtx(ctx context.Context, ex Executor,
+    set map[string]interface{}, filter string, params ...interface{}) (
+    int64, error) {
+
+	for _, col := range t.PKeys {
+		if _, ok := set[col.Name]; ok == true {
+			return 0, fmt.Errorf("matilda: Key %q can't be " +
+			    "updated.", col.Name)
+		}
+	}
+	if err := t.runSetValidators(ctx, set); err != nil {
+		return 0, err
+	}
+	if t.hasColumns(set) == false {
+		return 0, fmt.Errorf("matilda: No columns to update on " +
+		    "table %q.", t.Name)
+	}
+
+	n, err := t.drv.UpdateWhere(ctx, t.executor(ex), set, filter, params...)
+	if err != nil {
+		return 0, err
+	}
+	set[RES_ROWSAFFECTED] = n
+	return n, nil
 }
 
 func (t *Table) SelectByKey(cols []string, keys ...interface{}) (
@@ -194,13 +659,19 @@ func (t *Table) SelectByKey(cols []string, keys ...interface{}) (
 	return t.SelectByKeyTx(nil, cols, keys...)
 }
 
-func (t *Table) SelectByKeyTx(tx *sql.Tx, cols []string, keys ...interface{}) (
+func (t *Table) SelectByKeyTx(ex Executor, cols []string, keys ...interface{}) (
     map[string]interface{}, error) {
 
-	data, err := t.drv.SelectByKey(tx, cols, keys...)
+	return t.SelectByKeyCtx(context.Background(), ex, cols, keys...)
+}
+
+func (t *Table) SelectByKeyCtx(ctx context.Context, ex Executor,
+    cols []string, keys ...interface{}) (map[string]interface{}, error) {
+
+	data, err := t.drv.SelectByKey(ctx, t.executor(ex), cols, keys...)
 
 	// Validate each field ignoring errors
-	t.RunFieldValidators(data, DS_LOADED)
+	t.RunFieldValidatorsCtx(ctx, data, DS_LOADED)
 
 	return data, err
 }
@@ -211,13 +682,21 @@ func (t *Table) SelectOne(cols []string, filter string,
 	return t.SelectOneTx(nil, cols, filter, params...)
 }
 
-func (t *Table) SelectOneTx(tx *sql.Tx, cols []string, filter string,
+func (t *Table) SelectOneTx(ex Executor, cols []string, filter string,
     params ...interface{}) (map[string]interface{}, error) {
 
-	data, err := t.drv.SelectOne(tx, cols, filter, params...)
+	return t.SelectOneCtx(context.Background(), ex, cols, filter,
+	    params...)
+}
+
+func (t *Table) SelectOneCtx(ctx context.Context, ex Executor, cols []string,
+    filter string, params ...interface{}) (map[string]interface{}, error) {
+
+	data, err := t.drv.SelectOne(ctx, t.executor(ex), cols, filter,
+	    params...)
 
 	// Validate each field ignoring errors
-	t.RunFieldValidators(data, DS_LOADED)
+	t.RunFieldValidatorsCtx(ctx, data, DS_LOADED)
 
 	return data, err
 }
@@ -228,13 +707,23 @@ func (t *Table) Select(cols []string, filter string, params ...interface{}) (
 	return t.SelectTx(nil, cols, filter, params...)
 }
 
-func (t *Table) SelectTx(tx *sql.Tx, cols []string, filter string,
+func (t *Table) SelectTx(ex Executor, cols []string, filter string,
     params ...interface{}) (Rows, error) {
 
-	rows, err := t.drv.Select(tx, cols, filter, params...)
+	return t.SelectCtx(context.Background(), ex, cols, filter, params...)
+}
+
+// Rows stop when ctx is done
+func (t *Table) SelectCtx(ctx context.Context, ex Executor, cols []string,
+    filter string, params ...interface{}) (Rows, error) {
+
+	rows, err := t.drv.Select(ctx, t.executor(ex), cols, filter, params...)
+	if err != nil {
+		return nil, err
+	}
 	// For field validation
 	rows.SetFieldValidators(t)
-	return rows, err
+	return rows, nil
 }
 
 func (t *Table) Delete(data map[string]interface{}) error {
@@ -242,9 +731,15 @@ func (t *Table) Delete(data map[string]interface{}) error {
 	return t.DeleteTx(nil, data)
 }
 
-func (t *Table) DeleteTx(tx *sql.Tx, data map[string]interface{}) error {
+func (t *Table) DeleteTx(ex Executor, data map[string]interface{}) error {
+
+	return t.DeleteCtx(context.Background(), ex, data)
+}
 
-	if err := t.drv.Delete(tx, data); err != nil {
+func (t *Table) DeleteCtx(ctx context.Context, ex Executor,
+    data map[string]interface{}) error {
+
+	if err := t.drv.Delete(ctx, t.executor(ex), data); err != nil {
 		return err
 	}
 
@@ -254,6 +749,25 @@ func (t *Table) DeleteTx(tx *sql.Tx, data map[string]interface{}) error {
 	return nil
 }
 
+func (t *Table) DeleteWhere(filter string, params ...interface{}) (int64,
+    error) {
+
+	return t.DeleteWhereTx(nil, filter, params...)
+}
+
+func (t *Table) DeleteWhereTx(ex Executor, filter string,
+    params ...interface{}) (int64, error) {
+
+	return t.DeleteWhereCtx(context.Background(), ex, filter, params...)
+}
+
+// Delete the rows matching filter, all rows when it is empty
+func (t *Table) DeleteWhereCtx(ctx context.Context, ex Executor,
+    filter string, params ...interface{}) (int64, error) {
+
+	return t.drv.DeleteWhere(ctx, t.executor(ex), filter, params...)
+}
+
 func (t *Table) AddCol(name string, vdrs ...FieldValidator) {
 
 	t.addCol(NewCol(name, vdrs...))
@@ -273,3 +787,141 @@ func (t *Table) AddColAutoIncPK(name string, vdrs ...FieldValidator) {
 
 	t.addCol(NewColAutoIncPK(name, vdrs...))
 }
+
+func (t *Table) execStmts(ex Executor, stmts []string) error {
+
+	ex = t.executor(ex)
+	for _, stmt := range stmts {
+		_, err := ex.ExecContext(context.Background(), stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Table) schemaDriver() (SchemaDriver, error) {
+
+	if t.sdrv == nil {
+		return nil, fmt.Errorf("%w No schema driver for table %q.",
+		    ErrUnsupported, t.Name)
+	}
+	return t.sdrv, nil
+}
+
+// Statements creating the table on database
+func (t *Table) CreateTableSQL() ([]string, error) {
+
+	sdrv, err := t.schemaDriver()
+	if err != nil {
+		return nil, err
+	}
+	return sdrv.CreateTable()
+}
+
+func (t *Table) indexStmt(name string, concurrently bool,
+    create bool) (string, error) {
+
+	sdrv, err := t.schemaDriver()
+	if err != nil {
+		return "", err
+	}
+	idx := t.GetIndex(name)
+	if idx == nil {
+		return "", fmt.Errorf("matilda: Index %q not found on table " +
+		    "%q.", name, t.Name)
+	}
+	if create == true {
+		return sdrv.CreateIndex(idx, concurrently)
+	}
+	return sdrv.DropIndex(idx, concurrently)
+}
+
+func (t *Table) CreateTable() error {
+
+	return t.CreateTableTx(nil)
+}
+
+func (t *Table) CreateTableTx(ex Executor) error {
+
+	stmts, err := t.CreateTableSQL()
+	if err != nil {
+		return err
+	}
+	return t.execStmts(ex, stmts)
+}
+
+func (t *Table) DropTable() error {
+
+	return t.DropTableTx(nil)
+}
+
+func (t *Table) DropTableTx(ex Executor) error {
+
+	sdrv, err := t.schemaDriver()
+	if err != nil {
+		return err
+	}
+	stmt, err := sdrv.DropTable()
+	if err != nil {
+		return err
+	}
+	return t.execStmts(ex, []string{stmt})
+}
+
+// Compare the table with its database version
+func (t *Table) Diff() (*TableDiff, error) {
+
+	return t.DiffTx(nil)
+}
+
+func (t *Table) DiffTx(ex Executor) (*TableDiff, error) {
+
+	sdrv, err := t.schemaDriver()
+	if err != nil {
+		return nil, err
+	}
+	return sdrv.Diff(t.executor(ex))
+}
+
+// Create a declared index, concurrently can't be used inside a transaction
+func (t *Table) CreateIndex(name string, concurrently bool) error {
+
+	return t.CreateIndexTx(nil, name, concurrently)
+}
+
+func (t *Table) CreateIndexTx(ex Executor, name string,
+    concurrently bool) error {
+
+	stmt, err := t.indexStmt(name, concurrently, true)
+	if err != nil {
+		return err
+	}
+	return t.execStmts(ex, []string{stmt})
+}
+
+// Drop a declared index, concurrently can't be used inside a transaction
+func (t *Table) DropIndex(name string, concurrently bool) error {
+
+	return t.DropIndexTx(nil, name, concurrently)
+}
+
+func (t *Table) DropIndexTx(ex Executor, name string,
+    concurrently bool) error {
+
+	stmt, err := t.indexStmt(name, concurrently, false)
+	if err != nil {
+		return err
+	}
+	return t.execStmts(ex, []string{stmt})
+}
+
+func (t *Table) AddIndex(name string, cols ...string) *Index {
+
+	return t.addIndex(NewIndex(name, cols...))
+}
+
+func (t *Table) AddIndexUnique(name string, cols ...string) *Index {
+
+	return t.addIndex(NewIndexUnique(name, cols...))
+}
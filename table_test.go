@@ -0,0 +1,184 @@
+package matilda_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers/memory"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Table validator lowering names, checking the full row
+type lowerNames struct {
+	rows int
+}
+
+func (l *lowerNames) Validate(data map[string]interface{},
+    ds matilda.DataState) error {
+
+	l.rows++
+	if name, ok := data["name"].(string); ok == true {
+		data["name"] = strings.ToLower(name)
+	}
+	return nil
+}
+
+// Partial validator loading the row only when the name changes
+type partialNames struct {
+	loads int
+}
+
+func (p *partialNames) ValidatePartial(ctx context.Context,
+    data map[string]interface{},
+    merged func() (map[string]interface{}, error)) error {
+
+	if _, ok := data["name"]; ok == false {
+		return nil
+	}
+	row, err := merged()
+	if err != nil {
+		return err
+	}
+	p.loads++
+	if row["age"] == nil {
+		data["age"] = int64(0)
+	}
+	return nil
+}
+
+func openPatchTable(t *testing.T, parent interface{}) *matilda.Table {
+
+	db := openDB(t, memory.DriverName)
+	tb := matilda.NewTable(parent, db, "people",
+	    matilda.NewColAutoIncPK("id"),
+	    matilda.NewCol("name", &matilda.VdrString{}),
+	    matilda.NewCol("age", &matilda.VdrInt64{}))
+	err := tb.Insert(map[string]interface{}{"name": "ann", "age": 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tb
+}
+
+func TestPatch(t *testing.T) {
+
+	tb := openPatchTable(t, nil)
+	err := tb.Patch(map[string]interface{}{"id": 1, "age": 31})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := tb.SelectByKey(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row["name"] != "ann" || row["age"] != int64(31) {
+		t.Fatalf("got %v", row)
+	}
+
+	err = tb.Patch(map[string]interface{}{"id": 2, "age": 1})
+	if err == nil || err.Error() != "Record not found." {
+		t.Fatalf("got %v, want Record not found.", err)
+	}
+	if err = tb.Patch(map[string]interface{}{"age": 1}); err == nil {
+		t.Fatal("patched without keys")
+	}
+	if err = tb.Patch(map[string]interface{}{"id": 1}); err == nil {
+		t.Fatal("patched without columns")
+	}
+}
+
+func TestPatchTableValidator(t *testing.T) {
+	var v = new(lowerNames)
+
+	tb := openPatchTable(t, v)
+	data := map[string]interface{}{"id": 1, "name": "ANNA"}
+	if err := tb.Patch(data); err != nil {
+		t.Fatal(err)
+	}
+	if data["name"] != "anna" {
+		t.Fatalf("got name %v, want anna", data["name"])
+	}
+	if _, ok := data["age"]; ok == true {
+		t.Fatal("merged column added to the patch")
+	}
+	row, _ := tb.SelectByKey(nil, 1)
+	if row["name"] != "anna" || row["age"] != int64(30) {
+		t.Fatalf("got %v", row)
+	}
+}
+
+func TestPatchPartialValidator(t *testing.T) {
+	var v = new(partialNames)
+
+	tb := openPatchTable(t, v)
+	err := tb.Patch(map[string]interface{}{"id": 1, "age": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.loads != 0 {
+		t.Fatalf("got %d row loads, want 0", v.loads)
+	}
+	err = tb.Patch(map[string]interface{}{"id": 1, "name": "bo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.loads != 1 {
+		t.Fatalf("got %d row loads, want 1", v.loads)
+	}
+}
+
+func TestUpsertUpdateUnixNow(t *testing.T) {
+
+	db := openDB(t, memory.DriverName)
+	tb := matilda.NewTable(nil, db, "docs",
+	    matilda.NewColPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("title", &matilda.VdrString{}),
+	    matilda.NewCol("updated", &matilda.VdrInt64{UpdateUnixNow: true}))
+	err := tb.Insert(map[string]interface{}{"id": 1, "title": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, _ := tb.SelectByKey(nil, 1)
+	if row["updated"] != nil {
+		t.Fatalf("got updated %v on insert", row["updated"])
+	}
+
+	err = tb.Upsert(map[string]interface{}{"id": 1, "title": "b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row, err = tb.SelectByKey(nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	if row["title"] != "b" || row["updated"] == nil {
+		t.Fatalf("got %v, want title and updated set", row)
+	}
+}
+
+func TestUpsertPassword(t *testing.T) {
+
+	db := openDB(t, memory.DriverName)
+	tb := matilda.NewTable(nil, db, "logins",
+	    matilda.NewColPK("id", &matilda.VdrInt64{}),
+	    matilda.NewCol("pw", &matilda.VdrString{Password: true,
+	    MaxLen: 32}))
+	for _, pw := range []string{"secret", "other"} {
+		err := tb.Upsert(map[string]interface{}{"id": 1, "pw": pw}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row, err := tb.SelectByKey(nil, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, _ := row["pw"].([]byte)
+		if s, ok := row["pw"].(string); ok == true {
+			hash = []byte(s)
+		}
+		if bcrypt.CompareHashAndPassword(hash, []byte(pw)) != nil {
+			t.Fatalf("stored %q is not a hash of %s", hash, pw)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package matilda
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// InTx options
+type TxOpts struct {
+	// Transaction isolation level and access mode
+	Isolation sql.IsolationLevel
+	ReadOnly bool
+
+	// Attempts on serialization failures and deadlocks, 3 when zero
+	MaxAttempts int
+
+	// Wait before the first retry, doubled on each one; 10ms when zero
+	Backoff time.Duration
+}
+
+// Context key of the running InTx transaction
+type txKey struct{}
+
+type txState struct {
+	db *sql.DB
+	tx *sql.Tx
+
+	// Savepoint nesting level
+	depth int
+}
+
+// SQLSTATEs of serialization failures and deadlocks
+var retrySQLStates = map[string]bool{
+	"40001": true,
+	"40P01": true,
+}
+
+// Report errors worth running the transaction again, from drivers having
+// SQLState methods as pgx and lib/pq
+func isRetryable(err error) bool {
+	var se interface{ SQLState() string }
+
+	if errors.As(err, &se) == true {
+		return retrySQLStates[se.SQLState()]
+	}
+	return false
+}
+
+// Savepoint statements of the db driver
+func savepointDriver(db *sql.DB) (SavepointDriver, error) {
+
+	d, err := findDriver(crudDrivers, "", db)
+	if err != nil {
+		return nil, err
+	}
+	drv := d(&Table{db: db})
+	sp, ok := drv.(SavepointDriver)
+	if ok == false || drv.Capabilities() & CAP_SAVEPOINT == 0 {
+		return nil, fmt.Errorf("%w Database driver lacks %s.",
+		    ErrUnsupported, CAP_SAVEPOINT)
+	}
+	return sp, nil
+}
+
+// Run fn in a transaction on db, committing when it returns nil and rolling
+// back on errors and panics
+//
+// Calls nested through fn ctx on the same db run in a SAVEPOINT instead,
+// failing with ErrUnsupported when the driver lacks CAP_SAVEPOINT.
+// Serialization failures and deadlocks run the whole transaction again, so
+// fn must not have side effects out of the database.
+func InTx(ctx context.Context, db *sql.DB, opts *TxOpts,
+    fn func(context.Context, *sql.Tx) error) error {
+	var o TxOpts
+
+	if st, ok := ctx.Value(txKey{}).(*txState); ok == true && st.db == db {
+		return st.savepoint(ctx, fn)
+	}
+
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 10 * time.Millisecond
+	}
+	wait := o.Backoff
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, &o, fn)
+		if err == nil || attempt >= o.MaxAttempts ||
+		    isRetryable(err) == false {
+			return err
+		}
+
+		// Jitter keeps conflicting transactions apart
+		d := wait / 2 + time.Duration(rand.Int63n(int64(wait / 2) + 1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+		wait *= 2
+	}
+}
+
+func runTx(ctx context.Context, db *sql.DB, o *TxOpts,
+    fn func(context.Context, *sql.Tx) error) (err error) {
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: o.Isolation,
+	    ReadOnly: o.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	st := &txState{db: db, tx: tx}
+	if err = fn(context.WithValue(ctx, txKey{}, st), tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (st *txState) savepoint(ctx context.Context,
+    fn func(context.Context, *sql.Tx) error) (err error) {
+
+	sp, err := savepointDriver(st.db)
+	if err != nil {
+		return err
+	}
+	set, rollback, release := sp.Savepoint(fmt.Sprintf("matilda_sp_%d",
+	    st.depth + 1))
+	if _, err = st.tx.ExecContext(ctx, set); err != nil {
+		return err
+	}
+	// A failed rollback panics too, the outer runTx rolls everything back
+	defer func() {
+		if p := recover(); p != nil {
+			st.tx.ExecContext(ctx, rollback)
+			panic(p)
+		}
+	}()
+
+	inner := &txState{db: st.db, tx: st.tx, depth: st.depth + 1}
+	if err = fn(context.WithValue(ctx, txKey{}, inner), st.tx); err != nil {
+		if _, rerr := st.tx.ExecContext(ctx, rollback); rerr != nil {
+			return fmt.Errorf("%w (rollback to savepoint: %v)", err,
+			    rerr)
+		}
+		return err
+	}
+	if release != "" {
+		_, err = st.tx.ExecContext(ctx, release)
+	}
+	return err
+}
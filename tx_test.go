@@ -0,0 +1,234 @@
+package matilda_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/radixo/matilda"
+	"github.com/radixo/matilda/drivers/memory"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string {
+
+	return "SQLSTATE " + string(e)
+}
+
+func (e sqlStateError) SQLState() string {
+
+	return string(e)
+}
+
+func openTxTable(t *testing.T) (*sql.DB, *matilda.Table) {
+
+	memory.Reset(t.Name())
+	db, err := sql.Open(memory.DriverName, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		memory.Reset(t.Name())
+	})
+	return db, matilda.NewTable(nil, db, "items",
+	    matilda.NewColAutoIncPK("id"),
+	    matilda.NewCol("name", &matilda.VdrString{}))
+}
+
+func countRows(t *testing.T, tb *matilda.Table) int {
+	var n int
+
+	rows, err := tb.Select(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		n++
+	}
+	return n
+}
+
+func insertItem(ctx context.Context, tb *matilda.Table, tx *sql.Tx,
+    name string) error {
+
+	return tb.InsertCtx(ctx, tx, map[string]interface{}{"name": name})
+}
+
+func TestInTxCommitRollback(t *testing.T) {
+	var errFn = errors.New("fn failed")
+
+	db, tb := openTxTable(t)
+	ctx := context.Background()
+	err := matilda.InTx(ctx, db, nil,
+	    func(ctx context.Context, tx *sql.Tx) error {
+
+		return insertItem(ctx, tb, tx, "a")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = matilda.InTx(ctx, db, nil,
+	    func(ctx context.Context, tx *sql.Tx) error {
+
+		if err := insertItem(ctx, tb, tx, "b"); err != nil {
+			return err
+		}
+		return errFn
+	})
+	if errors.Is(err, errFn) == false {
+		t.Fatalf("got %v, want %v", err, errFn)
+	}
+	if n := countRows(t, tb); n != 1 {
+		t.Fatalf("got %d rows, want 1", n)
+	}
+}
+
+func TestInTxPanic(t *testing.T) {
+
+	db, tb := openTxTable(t)
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("got panic %v, want boom", p)
+			}
+		}()
+		matilda.InTx(context.Background(), db, nil,
+		    func(ctx context.Context, tx *sql.Tx) error {
+
+			insertItem(ctx, tb, tx, "a")
+			panic("boom")
+		})
+	}()
+	if n := countRows(t, tb); n != 0 {
+		t.Fatalf("got %d rows, want 0", n)
+	}
+}
+
+func TestInTxRetry(t *testing.T) {
+	var tests = []struct {
+		name string
+		err error
+		attempts int
+		calls int
+	}{
+		{"serialization", sqlStateError("40001"), 0, 3},
+		{"deadlock wrapped", fmt.Errorf("matilda driver Insert: %w",
+		    sqlStateError("40P01")), 5, 5},
+		{"unique violation", sqlStateError("23505"), 0, 1},
+		{"plain error", errors.New("plain"), 0, 1},
+	}
+
+	db, _ := openTxTable(t)
+	for _, tt := range tests {
+		var calls int
+
+		opts := &matilda.TxOpts{MaxAttempts: tt.attempts, Backoff: 1}
+		err := matilda.InTx(context.Background(), db, opts,
+		    func(ctx context.Context, tx *sql.Tx) error {
+
+			calls++
+			return tt.err
+		})
+		if err != tt.err {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s: got %d calls, want %d", tt.name, calls,
+			    tt.calls)
+		}
+	}
+}
+
+func TestInTxRetrySucceeds(t *testing.T) {
+	var calls int
+
+	db, tb := openTxTable(t)
+	err := matilda.InTx(context.Background(), db,
+	    &matilda.TxOpts{Backoff: 1},
+	    func(ctx context.Context, tx *sql.Tx) error {
+
+		calls++
+		if err := insertItem(ctx, tb, tx, "a"); err != nil {
+			return err
+		}
+		if calls < 3 {
+			return sqlStateError("40001")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, tb); n != 1 {
+		t.Fatalf("got %d rows, want 1", n)
+	}
+}
+
+func TestInTxNested(t *testing.T) {
+	var errFn = errors.New("fn failed")
+
+	db, tb := openTxTable(t)
+	nested := func(ctx context.Context, name string, err error) error {
+
+		return matilda.InTx(ctx, db, nil,
+		    func(ctx context.Context, tx *sql.Tx) error {
+
+			if err := insertItem(ctx, tb, tx, name); err != nil {
+				return err
+			}
+			return err
+		})
+	}
+	err := matilda.InTx(context.Background(), db, nil,
+	    func(ctx context.Context, tx *sql.Tx) error {
+
+		if err := insertItem(ctx, tb, tx, "a"); err != nil {
+			return err
+		}
+		if err := nested(ctx, "b", errFn); errors.Is(err,
+		    errFn) == false {
+			return fmt.Errorf("got %v, want %v", err, errFn)
+		}
+		return nested(ctx, "c", nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := tb.Select([]string{"name"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for rows.Next() {
+		row, err := rows.Tuple()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, row["name"].(string))
+	}
+	if fmt.Sprint(names) != "[a c]" {
+		t.Fatalf("got %v, want [a c]", names)
+	}
+}
+
+func TestInTxReadOnly(t *testing.T) {
+
+	db, tb := openTxTable(t)
+	opts := &matilda.TxOpts{Isolation: sql.LevelSerializable,
+	    ReadOnly: true}
+	err := matilda.InTx(context.Background(), db, opts,
+	    func(ctx context.Context, tx *sql.Tx) error {
+
+		return insertItem(ctx, tb, tx, "a")
+	})
+	if err == nil {
+		t.Fatal("read-only transaction wrote")
+	}
+	if n := countRows(t, tb); n != 0 {
+		t.Fatalf("got %d rows, want 0", n)
+	}
+}
@@ -63,7 +63,18 @@ func (uid *UID) Scan(src interface{}) error {
 	switch v := src.(type) {
 	case string:
 		s = v
+	case [uid_size]byte:
+		// Native uuid
+		uid.data = make([]byte, uid_size)
+		copy(uid.data, v[:])
+		return nil
 	case []byte:
+		if len(v) == uid_size {
+			// Raw bytes as returned by Value
+			uid.data = make([]byte, uid_size)
+			copy(uid.data, v)
+			return nil
+		}
 		s = string(v)
 	default:
 		s = fmt.Sprint(v)
@@ -95,7 +106,7 @@ func (vuid *VdrUID) ValidateField(data map[string]interface{}, fname string,
 	case nil:
 		val = v
 		goto _assert
-	case []byte, string:
+	case []byte, string, [uid_size]byte:
 		uid := UID{}
 		if err := uid.Scan(v); err != nil {
 			return err
@@ -1,6 +1,7 @@
 package matilda
 
 import (
+	"context"
 	"fmt"
 	"net/mail"
 	"strconv"
@@ -27,6 +28,24 @@ type FieldValidator interface {
 	ValidateField(map[string]interface{}, string, DataState) error
 }
 
+// TableValidators needing the context, e.g. for I/O
+type TableValidatorCtx interface {
+	ValidateCtx(context.Context, map[string]interface{}, DataState) error
+}
+
+// TableValidators checking partial updates, merged loads the full row
+// only when needed
+type PartialValidator interface {
+	ValidatePartial(ctx context.Context, data map[string]interface{},
+	    merged func() (map[string]interface{}, error)) error
+}
+
+// FieldValidators needing the context, used instead of ValidateField
+type FieldValidatorCtx interface {
+	ValidateFieldCtx(context.Context, map[string]interface{}, string,
+	    DataState) error
+}
+
 type VdrInt64 struct {
 	NotNull bool
 	Default interface{}
@@ -56,6 +75,13 @@ func (vi64 *VdrInt64) ValidateField(data map[string]interface{}, fname string,
 		val = int64(v)
 	case int64:
 		val = v
+	case time.Time:
+		// timestamp and timestamptz
+		if vi64.Timestamp == false {
+			return fmt.Errorf("matilda: Field %q must be int64.",
+			    fname)
+		}
+		val = v.Unix()
 	case string:
 		if vi64.Timestamp == true {
 			if t, err = time.Parse("2006-01-02", v); err == nil {